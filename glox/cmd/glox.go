@@ -2,43 +2,85 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 
 	"github.com/DomBlack/lox/glox/pkg/errs"
-	"github.com/DomBlack/lox/glox/pkg/interpreter"
-	"github.com/DomBlack/lox/glox/pkg/parser"
-	"github.com/DomBlack/lox/glox/pkg/scanner"
+	"github.com/DomBlack/lox/glox/pkg/lox"
 )
 
-var intpr = interpreter.New()
+var (
+	errorFormat = flag.String("error-format", "text", "how errors are written: text, plain or json")
+	color       = flag.String("color", "auto", "whether errors are colored: auto, always or never")
+)
+
+var runtime *lox.Runtime
 
 func main() {
-	switch len(os.Args) {
+	flag.Usage = func() {
+		println("Usage: glox [flags] [script | -]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	format, err := errs.ParseFormat(*errorFormat)
+	if err != nil {
+		println(err.Error())
+		os.Exit(1)
+	}
+	runtime = lox.New(lox.WithErrorFormat(format), lox.WithColor(useColor(*color)))
+
+	switch flag.NArg() {
 	default:
-		println("Usage: glox [script]")
+		flag.Usage()
 		os.Exit(1)
-	case 2:
-		runFile(os.Args[1])
 	case 1:
+		runFile(flag.Arg(0))
+	case 0:
 		runPrompt()
 	}
 }
 
-func runFile(path string) {
-	bytes, err := os.ReadFile(path)
-	if err != nil {
-		_, _ = fmt.Fprintf(os.Stderr, "failed to read file: %v", err)
-		os.Exit(2)
+// useColor reports whether errors should be colored for the given --color flag.
+func useColor(mode string) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
 	}
 
-	run(string(bytes))
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
 
-	switch {
-	case errs.HadError:
-		os.Exit(65)
-	case errs.HadRuntimeError:
+	info, err := os.Stderr.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// runFile runs the script at path, or the script piped to stdin if path is "-".
+func runFile(path string) {
+	src := os.Stdin
+	if path == "-" {
+		path = "<stdin>"
+	} else {
+		file, err := os.Open(path)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "failed to read file: %v", err)
+			os.Exit(2)
+		}
+		src = file
+	}
+
+	var runtimeErr *errs.RuntimeError
+	switch err := runtime.RunReader(context.Background(), path, src); {
+	case errors.As(err, &runtimeErr):
 		os.Exit(70)
+	case err != nil:
+		os.Exit(65)
 	default:
 		os.Exit(0)
 	}
@@ -52,25 +94,7 @@ func runPrompt() {
 			return
 		}
 
-		run(reader.Text())
-		errs.HadError = false
+		// Errors have already been reported, so the prompt just carries on.
+		_ = runtime.Run(reader.Text())
 	}
 }
-
-func run(source string) {
-	s := scanner.New(source)
-	tokens := s.ScanTokens()
-	p := parser.New(tokens)
-	stmts := p.Parse()
-
-	if errs.HadError {
-		return
-	}
-
-	intpr.Resolve(stmts)
-	if errs.HadError {
-		return
-	}
-
-	intpr.Interpret(stmts)
-}
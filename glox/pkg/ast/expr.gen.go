@@ -7,20 +7,33 @@ import (
 )
 
 type Expr interface {
+  Pos() token.Pos // position of the first character of the node
+  End() token.Pos // position just past the last character of the node
   _expr() // unexported interface
 }
 
 type ExprVisitor[R any] interface {
   VisitAssignExpr(v *AssignExpr) R
+  VisitBadExpr(v *BadExpr) R
   VisitBinaryExpr(v *BinaryExpr) R
   VisitCallExpr(v *CallExpr) R
+  VisitConditionalExpr(v *ConditionalExpr) R
+  VisitFunctionExpr(v *FunctionExpr) R
   VisitGetExpr(v *GetExpr) R
+  VisitOptionalChainExpr(v *OptionalChainExpr) R
+  VisitIndexExpr(v *IndexExpr) R
+  VisitIndexSetExpr(v *IndexSetExpr) R
   VisitGroupingExpr(v *GroupingExpr) R
   VisitLogicalExpr(v *LogicalExpr) R
+  VisitInterpolationExpr(v *InterpolationExpr) R
+  VisitListExpr(v *ListExpr) R
+  VisitMapExpr(v *MapExpr) R
   VisitLiteralExpr(v *LiteralExpr) R
   VisitSetExpr(v *SetExpr) R
+  VisitSliceExpr(v *SliceExpr) R
   VisitSuperExpr(v *SuperExpr) R
   VisitThisExpr(v *ThisExpr) R
+  VisitUpdateExpr(v *UpdateExpr) R
   VisitUnaryExpr(v *UnaryExpr) R
   VisitVariableExpr(v *VariableExpr) R
 }
@@ -29,24 +42,46 @@ func AcceptExpr[R any](e Expr, v ExprVisitor[R]) R {
   switch e := e.(type) {
   case *AssignExpr:
     return v.VisitAssignExpr(e)
+  case *BadExpr:
+    return v.VisitBadExpr(e)
   case *BinaryExpr:
     return v.VisitBinaryExpr(e)
   case *CallExpr:
     return v.VisitCallExpr(e)
+  case *ConditionalExpr:
+    return v.VisitConditionalExpr(e)
+  case *FunctionExpr:
+    return v.VisitFunctionExpr(e)
   case *GetExpr:
     return v.VisitGetExpr(e)
+  case *OptionalChainExpr:
+    return v.VisitOptionalChainExpr(e)
+  case *IndexExpr:
+    return v.VisitIndexExpr(e)
+  case *IndexSetExpr:
+    return v.VisitIndexSetExpr(e)
   case *GroupingExpr:
     return v.VisitGroupingExpr(e)
   case *LogicalExpr:
     return v.VisitLogicalExpr(e)
+  case *InterpolationExpr:
+    return v.VisitInterpolationExpr(e)
+  case *ListExpr:
+    return v.VisitListExpr(e)
+  case *MapExpr:
+    return v.VisitMapExpr(e)
   case *LiteralExpr:
     return v.VisitLiteralExpr(e)
   case *SetExpr:
     return v.VisitSetExpr(e)
+  case *SliceExpr:
+    return v.VisitSliceExpr(e)
   case *SuperExpr:
     return v.VisitSuperExpr(e)
   case *ThisExpr:
     return v.VisitThisExpr(e)
+  case *UpdateExpr:
+    return v.VisitUpdateExpr(e)
   case *UnaryExpr:
     return v.VisitUnaryExpr(e)
   case *VariableExpr:
@@ -58,12 +93,55 @@ func AcceptExpr[R any](e Expr, v ExprVisitor[R]) R {
 
 type AssignExpr struct {
   Name *token.Token
+  Operator *token.Token
   Value Expr
 }
 var _ Expr = (*AssignExpr)(nil)
 
 func (e *AssignExpr) _expr() {}
 
+func (e *AssignExpr) Pos() token.Pos {
+  if e.Name != nil {
+    return e.Name.Start
+  }
+  return token.NoPos
+}
+
+func (e *AssignExpr) End() token.Pos {
+  if e.Value != nil {
+    return e.Value.End()
+  }
+  if e.Operator != nil {
+    return e.Operator.End
+  }
+  if e.Name != nil {
+    return e.Name.End
+  }
+  return token.NoPos
+}
+
+type BadExpr struct {
+  From *token.Token
+  To *token.Token
+}
+var _ Expr = (*BadExpr)(nil)
+
+func (e *BadExpr) _expr() {}
+
+func (e *BadExpr) Pos() token.Pos {
+  if e.From != nil {
+    return e.From.Start
+  }
+  return token.NoPos
+}
+
+func (e *BadExpr) End() token.Pos {
+  if e.To != nil {
+    return e.To.End
+  }
+  return token.NoPos
+}
+
 type BinaryExpr struct {
   Left Expr
   Operator *token.Token
@@ -73,6 +151,26 @@ var _ Expr = (*BinaryExpr)(nil)
 
 func (e *BinaryExpr) _expr() {}
 
+func (e *BinaryExpr) Pos() token.Pos {
+  if e.Left != nil {
+    return e.Left.Pos()
+  }
+  if e.Operator != nil {
+    return e.Operator.Start
+  }
+  return token.NoPos
+}
+
+func (e *BinaryExpr) End() token.Pos {
+  if e.Right != nil {
+    return e.Right.End()
+  }
+  if e.Operator != nil {
+    return e.Operator.End
+  }
+  return token.NoPos
+}
+
 type CallExpr struct {
   Callee Expr
   Paren *token.Token
@@ -82,21 +180,226 @@ var _ Expr = (*CallExpr)(nil)
 
 func (e *CallExpr) _expr() {}
 
+func (e *CallExpr) Pos() token.Pos {
+  if e.Callee != nil {
+    return e.Callee.Pos()
+  }
+  if e.Paren != nil {
+    return e.Paren.Start
+  }
+  return token.NoPos
+}
+
+func (e *CallExpr) End() token.Pos {
+  if e.Paren != nil {
+    return e.Paren.End
+  }
+  return token.NoPos
+}
+
+type ConditionalExpr struct {
+  Condition Expr
+  Question *token.Token
+  ThenBranch Expr
+  Colon *token.Token
+  ElseBranch Expr
+}
+var _ Expr = (*ConditionalExpr)(nil)
+
+func (e *ConditionalExpr) _expr() {}
+
+func (e *ConditionalExpr) Pos() token.Pos {
+  if e.Condition != nil {
+    return e.Condition.Pos()
+  }
+  if e.Question != nil {
+    return e.Question.Start
+  }
+  return token.NoPos
+}
+
+func (e *ConditionalExpr) End() token.Pos {
+  if e.ElseBranch != nil {
+    return e.ElseBranch.End()
+  }
+  if e.Colon != nil {
+    return e.Colon.End
+  }
+  return token.NoPos
+}
+
+type FunctionExpr struct {
+  Keyword *token.Token
+  LeftParen *token.Token
+  Params []*token.Token
+  Body []Stmt
+  RightBrace *token.Token
+}
+var _ Expr = (*FunctionExpr)(nil)
+
+func (e *FunctionExpr) _expr() {}
+
+func (e *FunctionExpr) Pos() token.Pos {
+  if e.Keyword != nil {
+    return e.Keyword.Start
+  }
+  if e.LeftParen != nil {
+    return e.LeftParen.Start
+  }
+  return token.NoPos
+}
+
+func (e *FunctionExpr) End() token.Pos {
+  if e.RightBrace != nil {
+    return e.RightBrace.End
+  }
+  if len(e.Body) > 0 {
+    return e.Body[len(e.Body)-1].End()
+  }
+  return token.NoPos
+}
+
 type GetExpr struct {
   Object Expr
   Name *token.Token
+  Optional bool
 }
 var _ Expr = (*GetExpr)(nil)
 
 func (e *GetExpr) _expr() {}
 
+func (e *GetExpr) Pos() token.Pos {
+  if e.Object != nil {
+    return e.Object.Pos()
+  }
+  if e.Name != nil {
+    return e.Name.Start
+  }
+  return token.NoPos
+}
+
+func (e *GetExpr) End() token.Pos {
+  if e.Name != nil {
+    return e.Name.End
+  }
+  return token.NoPos
+}
+
+type OptionalChainExpr struct {
+  Expression Expr
+}
+var _ Expr = (*OptionalChainExpr)(nil)
+
+func (e *OptionalChainExpr) _expr() {}
+
+func (e *OptionalChainExpr) Pos() token.Pos {
+  if e.Expression != nil {
+    return e.Expression.Pos()
+  }
+  return token.NoPos
+}
+
+func (e *OptionalChainExpr) End() token.Pos {
+  if e.Expression != nil {
+    return e.Expression.End()
+  }
+  return token.NoPos
+}
+
+type IndexExpr struct {
+  Object Expr
+  LeftBracket *token.Token
+  Index Expr
+  RightBracket *token.Token
+}
+var _ Expr = (*IndexExpr)(nil)
+
+func (e *IndexExpr) _expr() {}
+
+func (e *IndexExpr) Pos() token.Pos {
+  if e.Object != nil {
+    return e.Object.Pos()
+  }
+  if e.LeftBracket != nil {
+    return e.LeftBracket.Start
+  }
+  return token.NoPos
+}
+
+func (e *IndexExpr) End() token.Pos {
+  if e.RightBracket != nil {
+    return e.RightBracket.End
+  }
+  if e.Index != nil {
+    return e.Index.End()
+  }
+  return token.NoPos
+}
+
+type IndexSetExpr struct {
+  Object Expr
+  LeftBracket *token.Token
+  Index Expr
+  RightBracket *token.Token
+  Operator *token.Token
+  Value Expr
+}
+var _ Expr = (*IndexSetExpr)(nil)
+
+func (e *IndexSetExpr) _expr() {}
+
+func (e *IndexSetExpr) Pos() token.Pos {
+  if e.Object != nil {
+    return e.Object.Pos()
+  }
+  if e.LeftBracket != nil {
+    return e.LeftBracket.Start
+  }
+  return token.NoPos
+}
+
+func (e *IndexSetExpr) End() token.Pos {
+  if e.Value != nil {
+    return e.Value.End()
+  }
+  if e.Operator != nil {
+    return e.Operator.End
+  }
+  if e.RightBracket != nil {
+    return e.RightBracket.End
+  }
+  return token.NoPos
+}
+
 type GroupingExpr struct {
+  LeftParen *token.Token
   Expression Expr
+  RightParen *token.Token
 }
 var _ Expr = (*GroupingExpr)(nil)
 
 func (e *GroupingExpr) _expr() {}
 
+func (e *GroupingExpr) Pos() token.Pos {
+  if e.LeftParen != nil {
+    return e.LeftParen.Start
+  }
+  if e.Expression != nil {
+    return e.Expression.Pos()
+  }
+  return token.NoPos
+}
+
+func (e *GroupingExpr) End() token.Pos {
+  if e.RightParen != nil {
+    return e.RightParen.End
+  }
+  if e.Expression != nil {
+    return e.Expression.End()
+  }
+  return token.NoPos
+}
+
 type LogicalExpr struct {
   Left Expr
   Operator *token.Token
@@ -106,22 +409,190 @@ var _ Expr = (*LogicalExpr)(nil)
 
 func (e *LogicalExpr) _expr() {}
 
+func (e *LogicalExpr) Pos() token.Pos {
+  if e.Left != nil {
+    return e.Left.Pos()
+  }
+  if e.Operator != nil {
+    return e.Operator.Start
+  }
+  return token.NoPos
+}
+
+func (e *LogicalExpr) End() token.Pos {
+  if e.Right != nil {
+    return e.Right.End()
+  }
+  if e.Operator != nil {
+    return e.Operator.End
+  }
+  return token.NoPos
+}
+
+type InterpolationExpr struct {
+  Parts []Expr
+}
+var _ Expr = (*InterpolationExpr)(nil)
+
+func (e *InterpolationExpr) _expr() {}
+
+func (e *InterpolationExpr) Pos() token.Pos {
+  if len(e.Parts) > 0 {
+    return e.Parts[0].Pos()
+  }
+  return token.NoPos
+}
+
+func (e *InterpolationExpr) End() token.Pos {
+  if len(e.Parts) > 0 {
+    return e.Parts[len(e.Parts)-1].End()
+  }
+  return token.NoPos
+}
+
+type ListExpr struct {
+  LeftBracket *token.Token
+  Elements []Expr
+  RightBracket *token.Token
+}
+var _ Expr = (*ListExpr)(nil)
+
+func (e *ListExpr) _expr() {}
+
+func (e *ListExpr) Pos() token.Pos {
+  if e.LeftBracket != nil {
+    return e.LeftBracket.Start
+  }
+  if len(e.Elements) > 0 {
+    return e.Elements[0].Pos()
+  }
+  return token.NoPos
+}
+
+func (e *ListExpr) End() token.Pos {
+  if e.RightBracket != nil {
+    return e.RightBracket.End
+  }
+  if len(e.Elements) > 0 {
+    return e.Elements[len(e.Elements)-1].End()
+  }
+  return token.NoPos
+}
+
+type MapExpr struct {
+  LeftBrace *token.Token
+  Keys []Expr
+  Values []Expr
+  RightBrace *token.Token
+}
+var _ Expr = (*MapExpr)(nil)
+
+func (e *MapExpr) _expr() {}
+
+func (e *MapExpr) Pos() token.Pos {
+  if e.LeftBrace != nil {
+    return e.LeftBrace.Start
+  }
+  return token.NoPos
+}
+
+func (e *MapExpr) End() token.Pos {
+  if e.RightBrace != nil {
+    return e.RightBrace.End
+  }
+  return token.NoPos
+}
+
 type LiteralExpr struct {
+  Token *token.Token
   Value any
 }
 var _ Expr = (*LiteralExpr)(nil)
 
 func (e *LiteralExpr) _expr() {}
 
+func (e *LiteralExpr) Pos() token.Pos {
+  if e.Token != nil {
+    return e.Token.Start
+  }
+  return token.NoPos
+}
+
+func (e *LiteralExpr) End() token.Pos {
+  if e.Token != nil {
+    return e.Token.End
+  }
+  return token.NoPos
+}
+
 type SetExpr struct {
   Object Expr
   Name *token.Token
+  Operator *token.Token
   Value Expr
 }
 var _ Expr = (*SetExpr)(nil)
 
 func (e *SetExpr) _expr() {}
 
+func (e *SetExpr) Pos() token.Pos {
+  if e.Object != nil {
+    return e.Object.Pos()
+  }
+  if e.Name != nil {
+    return e.Name.Start
+  }
+  return token.NoPos
+}
+
+func (e *SetExpr) End() token.Pos {
+  if e.Value != nil {
+    return e.Value.End()
+  }
+  if e.Operator != nil {
+    return e.Operator.End
+  }
+  if e.Name != nil {
+    return e.Name.End
+  }
+  return token.NoPos
+}
+
+type SliceExpr struct {
+  Object Expr
+  LeftBracket *token.Token
+  Low Expr
+  Colon *token.Token
+  High Expr
+  RightBracket *token.Token
+}
+var _ Expr = (*SliceExpr)(nil)
+
+func (e *SliceExpr) _expr() {}
+
+func (e *SliceExpr) Pos() token.Pos {
+  if e.Object != nil {
+    return e.Object.Pos()
+  }
+  if e.LeftBracket != nil {
+    return e.LeftBracket.Start
+  }
+  return token.NoPos
+}
+
+func (e *SliceExpr) End() token.Pos {
+  if e.RightBracket != nil {
+    return e.RightBracket.End
+  }
+  if e.High != nil {
+    return e.High.End()
+  }
+  if e.Colon != nil {
+    return e.Colon.End
+  }
+  return token.NoPos
+}
+
 type SuperExpr struct {
   Keyword *token.Token
   Method *token.Token
@@ -130,6 +601,23 @@ var _ Expr = (*SuperExpr)(nil)
 
 func (e *SuperExpr) _expr() {}
 
+func (e *SuperExpr) Pos() token.Pos {
+  if e.Keyword != nil {
+    return e.Keyword.Start
+  }
+  return token.NoPos
+}
+
+func (e *SuperExpr) End() token.Pos {
+  if e.Method != nil {
+    return e.Method.End
+  }
+  if e.Keyword != nil {
+    return e.Keyword.End
+  }
+  return token.NoPos
+}
+
 type ThisExpr struct {
   Keyword *token.Token
 }
@@ -137,6 +625,49 @@ var _ Expr = (*ThisExpr)(nil)
 
 func (e *ThisExpr) _expr() {}
 
+func (e *ThisExpr) Pos() token.Pos {
+  if e.Keyword != nil {
+    return e.Keyword.Start
+  }
+  return token.NoPos
+}
+
+func (e *ThisExpr) End() token.Pos {
+  if e.Keyword != nil {
+    return e.Keyword.End
+  }
+  return token.NoPos
+}
+
+type UpdateExpr struct {
+  Prefix *token.Token
+  Target Expr
+  Postfix *token.Token
+}
+var _ Expr = (*UpdateExpr)(nil)
+
+func (e *UpdateExpr) _expr() {}
+
+func (e *UpdateExpr) Pos() token.Pos {
+  if e.Prefix != nil {
+    return e.Prefix.Start
+  }
+  if e.Target != nil {
+    return e.Target.Pos()
+  }
+  return token.NoPos
+}
+
+func (e *UpdateExpr) End() token.Pos {
+  if e.Postfix != nil {
+    return e.Postfix.End
+  }
+  if e.Target != nil {
+    return e.Target.End()
+  }
+  return token.NoPos
+}
+
 type UnaryExpr struct {
   Operator *token.Token
   Right Expr
@@ -145,6 +676,23 @@ var _ Expr = (*UnaryExpr)(nil)
 
 func (e *UnaryExpr) _expr() {}
 
+func (e *UnaryExpr) Pos() token.Pos {
+  if e.Operator != nil {
+    return e.Operator.Start
+  }
+  return token.NoPos
+}
+
+func (e *UnaryExpr) End() token.Pos {
+  if e.Right != nil {
+    return e.Right.End()
+  }
+  if e.Operator != nil {
+    return e.Operator.End
+  }
+  return token.NoPos
+}
+
 type VariableExpr struct {
   Name *token.Token
 }
@@ -152,3 +700,17 @@ var _ Expr = (*VariableExpr)(nil)
 
 func (e *VariableExpr) _expr() {}
 
+func (e *VariableExpr) Pos() token.Pos {
+  if e.Name != nil {
+    return e.Name.Start
+  }
+  return token.NoPos
+}
+
+func (e *VariableExpr) End() token.Pos {
+  if e.Name != nil {
+    return e.Name.End
+  }
+  return token.NoPos
+}
+
@@ -3,6 +3,8 @@ package ast
 import (
 	"fmt"
 	"strings"
+
+	"github.com/DomBlack/lox/glox/pkg/token"
 )
 
 func Print(expr Expr) string {
@@ -19,7 +21,11 @@ func (p *printer) Print(expr Expr) string {
 }
 
 func (p *printer) VisitAssignExpr(v *AssignExpr) string {
-	return fmt.Sprintf("%s = %s", v.Name.Lexeme, p.Print(v.Value))
+	return fmt.Sprintf("%s %s %s", v.Name.Lexeme, operator(v.Operator), p.Print(v.Value))
+}
+
+func (p *printer) VisitBadExpr(_ *BadExpr) string {
+	return "<bad expression>"
 }
 
 func (p *printer) VisitBinaryExpr(v *BinaryExpr) string {
@@ -30,6 +36,10 @@ func (p *printer) VisitGroupingExpr(v *GroupingExpr) string {
 	return parenthesize("group", v.Expression)
 }
 
+func (p *printer) VisitInterpolationExpr(v *InterpolationExpr) string {
+	return parenthesize("interpolate", v.Parts...)
+}
+
 func (p *printer) VisitLiteralExpr(v *LiteralExpr) string {
 	if v.Value == nil {
 		return "nil"
@@ -57,12 +67,70 @@ func (p *printer) VisitCallExpr(v *CallExpr) string {
 	return builder.String()
 }
 
+func (p *printer) VisitFunctionExpr(v *FunctionExpr) string {
+	params := make([]string, len(v.Params))
+	for i, param := range v.Params {
+		params[i] = param.Lexeme
+	}
+	return fmt.Sprintf("(fun (%s))", strings.Join(params, " "))
+}
+
+func (p *printer) VisitConditionalExpr(v *ConditionalExpr) string {
+	return parenthesize("?:", v.Condition, v.ThenBranch, v.ElseBranch)
+}
+
 func (p *printer) VisitGetExpr(v *GetExpr) string {
+	if v.Optional {
+		return fmt.Sprintf("%s?.%s", p.Print(v.Object), v.Name.Lexeme)
+	}
 	return fmt.Sprintf("%s.%s", p.Print(v.Object), v.Name.Lexeme)
 }
 
+func (p *printer) VisitIndexExpr(v *IndexExpr) string {
+	return fmt.Sprintf("%s[%s]", p.Print(v.Object), p.Print(v.Index))
+}
+
+func (p *printer) VisitIndexSetExpr(v *IndexSetExpr) string {
+	return fmt.Sprintf("%s[%s] %s %s", p.Print(v.Object), p.Print(v.Index), operator(v.Operator), p.Print(v.Value))
+}
+
+func (p *printer) VisitListExpr(v *ListExpr) string {
+	return parenthesize("list", v.Elements...)
+}
+
+func (p *printer) VisitMapExpr(v *MapExpr) string {
+	entries := make([]Expr, 0, 2*len(v.Keys))
+	for i, key := range v.Keys {
+		entries = append(entries, key, v.Values[i])
+	}
+	return parenthesize("map", entries...)
+}
+
+func (p *printer) VisitSliceExpr(v *SliceExpr) string {
+	var low, high string
+	if v.Low != nil {
+		low = p.Print(v.Low)
+	}
+	if v.High != nil {
+		high = p.Print(v.High)
+	}
+	return fmt.Sprintf("%s[%s:%s]", p.Print(v.Object), low, high)
+}
+
+func (p *printer) VisitOptionalChainExpr(v *OptionalChainExpr) string {
+	return p.Print(v.Expression)
+}
+
 func (p *printer) VisitSetExpr(v *SetExpr) string {
-	return fmt.Sprintf("%s.%s = %s", p.Print(v.Object), v.Name.Lexeme, p.Print(v.Value))
+	return fmt.Sprintf("%s.%s %s %s", p.Print(v.Object), v.Name.Lexeme, operator(v.Operator), p.Print(v.Value))
+}
+
+// operator returns the lexeme of an assignment operator, which is "=" if unset.
+func operator(t *token.Token) string {
+	if t == nil {
+		return "="
+	}
+	return t.Lexeme
 }
 
 func (p *printer) VisitSuperExpr(v *SuperExpr) string {
@@ -77,6 +145,13 @@ func (p *printer) VisitUnaryExpr(v *UnaryExpr) string {
 	return parenthesize(v.Operator.Lexeme, v.Right)
 }
 
+func (p *printer) VisitUpdateExpr(v *UpdateExpr) string {
+	if v.Prefix != nil {
+		return parenthesize(v.Prefix.Lexeme, v.Target)
+	}
+	return fmt.Sprintf("(%s %s)", p.Print(v.Target), v.Postfix.Lexeme)
+}
+
 func (p *printer) VisitVariableExpr(v *VariableExpr) string {
 	return v.Name.Lexeme
 }
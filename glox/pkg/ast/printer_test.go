@@ -7,21 +7,23 @@ import (
 )
 
 func TestPrinter(t *testing.T) {
-	expression := &Binary{
-		Left: &Unary{
+	expression := &BinaryExpr{
+		Left: &UnaryExpr{
 			Operator: &token.Token{Type: token.MINUS, Lexeme: "-", Line: 1},
-			Right: &Literal{
+			Right: &LiteralExpr{
 				Value: 123,
 			},
 		},
 		Operator: &token.Token{Type: token.STAR, Lexeme: "*", Line: 1},
-		Right: &Grouping{
-			Expression: &Literal{
+		Right: &GroupingExpr{
+			Expression: &LiteralExpr{
 				Value: 45.67,
 			},
 		},
 	}
 
 	printer := &printer{}
-	println(printer.Print(expression))
+	if got, want := printer.Print(expression), "(* (- 123) (group 45.67))"; got != want {
+		t.Errorf("Print() = %q, want %q", got, want)
+	}
 }
@@ -7,12 +7,17 @@ import (
 )
 
 type Stmt interface {
+  Pos() token.Pos // position of the first character of the node
+  End() token.Pos // position just past the last character of the node
   _stmt() // unexported interface
 }
 
 type StmtVisitor[R any] interface {
+  VisitBadStmt(v *BadStmt) R
+  VisitBreakStmt(v *BreakStmt) R
   VisitBlockStmt(v *BlockStmt) R
   VisitClassStmt(v *ClassStmt) R
+  VisitContinueStmt(v *ContinueStmt) R
   VisitExpressionStmt(v *ExpressionStmt) R
   VisitFunctionStmt(v *FunctionStmt) R
   VisitIfStmt(v *IfStmt) R
@@ -24,10 +29,16 @@ type StmtVisitor[R any] interface {
 
 func AcceptStmt[R any](e Stmt, v StmtVisitor[R]) R {
   switch e := e.(type) {
+  case *BadStmt:
+    return v.VisitBadStmt(e)
+  case *BreakStmt:
+    return v.VisitBreakStmt(e)
   case *BlockStmt:
     return v.VisitBlockStmt(e)
   case *ClassStmt:
     return v.VisitClassStmt(e)
+  case *ContinueStmt:
+    return v.VisitContinueStmt(e)
   case *ExpressionStmt:
     return v.VisitExpressionStmt(e)
   case *FunctionStmt:
@@ -47,39 +58,216 @@ func AcceptStmt[R any](e Stmt, v StmtVisitor[R]) R {
   }
 }
 
+type BadStmt struct {
+  From *token.Token
+  To *token.Token
+}
+var _ Stmt = (*BadStmt)(nil)
+
+func (e *BadStmt) _stmt() {}
+
+func (e *BadStmt) Pos() token.Pos {
+  if e.From != nil {
+    return e.From.Start
+  }
+  return token.NoPos
+}
+
+func (e *BadStmt) End() token.Pos {
+  if e.To != nil {
+    return e.To.End
+  }
+  return token.NoPos
+}
+
+type BreakStmt struct {
+  Keyword *token.Token
+  Label *token.Token
+  Semicolon *token.Token
+}
+var _ Stmt = (*BreakStmt)(nil)
+
+func (e *BreakStmt) _stmt() {}
+
+func (e *BreakStmt) Pos() token.Pos {
+  if e.Keyword != nil {
+    return e.Keyword.Start
+  }
+  return token.NoPos
+}
+
+func (e *BreakStmt) End() token.Pos {
+  if e.Semicolon != nil {
+    return e.Semicolon.End
+  }
+  if e.Label != nil {
+    return e.Label.End
+  }
+  if e.Keyword != nil {
+    return e.Keyword.End
+  }
+  return token.NoPos
+}
+
 type BlockStmt struct {
+  LeftBrace *token.Token
   Statements []Stmt
+  RightBrace *token.Token
 }
 var _ Stmt = (*BlockStmt)(nil)
 
 func (e *BlockStmt) _stmt() {}
 
+func (e *BlockStmt) Pos() token.Pos {
+  if e.LeftBrace != nil {
+    return e.LeftBrace.Start
+  }
+  if len(e.Statements) > 0 {
+    return e.Statements[0].Pos()
+  }
+  return token.NoPos
+}
+
+func (e *BlockStmt) End() token.Pos {
+  if e.RightBrace != nil {
+    return e.RightBrace.End
+  }
+  if len(e.Statements) > 0 {
+    return e.Statements[len(e.Statements)-1].End()
+  }
+  return token.NoPos
+}
+
 type ClassStmt struct {
+  Keyword *token.Token
   Name *token.Token
   Superclass *VariableExpr
   Methods []*FunctionStmt
+  RightBrace *token.Token
 }
 var _ Stmt = (*ClassStmt)(nil)
 
 func (e *ClassStmt) _stmt() {}
 
+func (e *ClassStmt) Pos() token.Pos {
+  if e.Keyword != nil {
+    return e.Keyword.Start
+  }
+  if e.Name != nil {
+    return e.Name.Start
+  }
+  return token.NoPos
+}
+
+func (e *ClassStmt) End() token.Pos {
+  if e.RightBrace != nil {
+    return e.RightBrace.End
+  }
+  if len(e.Methods) > 0 {
+    return e.Methods[len(e.Methods)-1].End()
+  }
+  if e.Superclass != nil {
+    return e.Superclass.End()
+  }
+  if e.Name != nil {
+    return e.Name.End
+  }
+  return token.NoPos
+}
+
+type ContinueStmt struct {
+  Keyword *token.Token
+  Label *token.Token
+  Semicolon *token.Token
+}
+var _ Stmt = (*ContinueStmt)(nil)
+
+func (e *ContinueStmt) _stmt() {}
+
+func (e *ContinueStmt) Pos() token.Pos {
+  if e.Keyword != nil {
+    return e.Keyword.Start
+  }
+  return token.NoPos
+}
+
+func (e *ContinueStmt) End() token.Pos {
+  if e.Semicolon != nil {
+    return e.Semicolon.End
+  }
+  if e.Label != nil {
+    return e.Label.End
+  }
+  if e.Keyword != nil {
+    return e.Keyword.End
+  }
+  return token.NoPos
+}
+
 type ExpressionStmt struct {
   Expression Expr
+  Semicolon *token.Token
 }
 var _ Stmt = (*ExpressionStmt)(nil)
 
 func (e *ExpressionStmt) _stmt() {}
 
+func (e *ExpressionStmt) Pos() token.Pos {
+  if e.Expression != nil {
+    return e.Expression.Pos()
+  }
+  if e.Semicolon != nil {
+    return e.Semicolon.Start
+  }
+  return token.NoPos
+}
+
+func (e *ExpressionStmt) End() token.Pos {
+  if e.Semicolon != nil {
+    return e.Semicolon.End
+  }
+  if e.Expression != nil {
+    return e.Expression.End()
+  }
+  return token.NoPos
+}
+
 type FunctionStmt struct {
+  Keyword *token.Token
   Name *token.Token
   Params []*token.Token
   Body []Stmt
+  RightBrace *token.Token
 }
 var _ Stmt = (*FunctionStmt)(nil)
 
 func (e *FunctionStmt) _stmt() {}
 
+func (e *FunctionStmt) Pos() token.Pos {
+  if e.Keyword != nil {
+    return e.Keyword.Start
+  }
+  if e.Name != nil {
+    return e.Name.Start
+  }
+  return token.NoPos
+}
+
+func (e *FunctionStmt) End() token.Pos {
+  if e.RightBrace != nil {
+    return e.RightBrace.End
+  }
+  if len(e.Body) > 0 {
+    return e.Body[len(e.Body)-1].End()
+  }
+  if e.Name != nil {
+    return e.Name.End
+  }
+  return token.NoPos
+}
+
 type IfStmt struct {
+  Keyword *token.Token
   Condition Expr
   ThenBranch Stmt
   ElseBranch Stmt
@@ -88,34 +276,151 @@ var _ Stmt = (*IfStmt)(nil)
 
 func (e *IfStmt) _stmt() {}
 
+func (e *IfStmt) Pos() token.Pos {
+  if e.Keyword != nil {
+    return e.Keyword.Start
+  }
+  if e.Condition != nil {
+    return e.Condition.Pos()
+  }
+  return token.NoPos
+}
+
+func (e *IfStmt) End() token.Pos {
+  if e.ElseBranch != nil {
+    return e.ElseBranch.End()
+  }
+  if e.ThenBranch != nil {
+    return e.ThenBranch.End()
+  }
+  return token.NoPos
+}
+
 type PrintStmt struct {
+  Keyword *token.Token
   Expression Expr
+  Semicolon *token.Token
 }
 var _ Stmt = (*PrintStmt)(nil)
 
 func (e *PrintStmt) _stmt() {}
 
+func (e *PrintStmt) Pos() token.Pos {
+  if e.Keyword != nil {
+    return e.Keyword.Start
+  }
+  return token.NoPos
+}
+
+func (e *PrintStmt) End() token.Pos {
+  if e.Semicolon != nil {
+    return e.Semicolon.End
+  }
+  if e.Expression != nil {
+    return e.Expression.End()
+  }
+  if e.Keyword != nil {
+    return e.Keyword.End
+  }
+  return token.NoPos
+}
+
 type ReturnStmt struct {
   Keyword *token.Token
   Value Expr
+  Semicolon *token.Token
 }
 var _ Stmt = (*ReturnStmt)(nil)
 
 func (e *ReturnStmt) _stmt() {}
 
+func (e *ReturnStmt) Pos() token.Pos {
+  if e.Keyword != nil {
+    return e.Keyword.Start
+  }
+  return token.NoPos
+}
+
+func (e *ReturnStmt) End() token.Pos {
+  if e.Semicolon != nil {
+    return e.Semicolon.End
+  }
+  if e.Value != nil {
+    return e.Value.End()
+  }
+  if e.Keyword != nil {
+    return e.Keyword.End
+  }
+  return token.NoPos
+}
+
 type VarStmt struct {
+  Keyword *token.Token
   Name *token.Token
   Initializer Expr
+  Semicolon *token.Token
 }
 var _ Stmt = (*VarStmt)(nil)
 
 func (e *VarStmt) _stmt() {}
 
+func (e *VarStmt) Pos() token.Pos {
+  if e.Keyword != nil {
+    return e.Keyword.Start
+  }
+  if e.Name != nil {
+    return e.Name.Start
+  }
+  return token.NoPos
+}
+
+func (e *VarStmt) End() token.Pos {
+  if e.Semicolon != nil {
+    return e.Semicolon.End
+  }
+  if e.Initializer != nil {
+    return e.Initializer.End()
+  }
+  if e.Name != nil {
+    return e.Name.End
+  }
+  return token.NoPos
+}
+
 type WhileStmt struct {
+  Label *token.Token
+  Keyword *token.Token
   Condition Expr
   Body Stmt
+  Increment Expr
 }
 var _ Stmt = (*WhileStmt)(nil)
 
 func (e *WhileStmt) _stmt() {}
 
+func (e *WhileStmt) Pos() token.Pos {
+  if e.Label != nil {
+    return e.Label.Start
+  }
+  if e.Keyword != nil {
+    return e.Keyword.Start
+  }
+  if e.Condition != nil {
+    return e.Condition.Pos()
+  }
+  return token.NoPos
+}
+
+func (e *WhileStmt) End() token.Pos {
+  if e.Body != nil {
+    return e.Body.End()
+  }
+  if e.Increment != nil {
+    return e.Increment.End()
+  }
+  if e.Condition != nil {
+    return e.Condition.End()
+  }
+  return token.NoPos
+}
+
@@ -0,0 +1,43 @@
+package errs
+
+// Code is a stable identifier for a kind of diagnostic, which will not change
+// even if the wording of the message does.
+type Code string
+
+// Scanner errors.
+const (
+	UnexpectedCharacter Code = "E0001"
+	UnterminatedString  Code = "E0002"
+	UnterminatedComment Code = "E0003"
+	InvalidEscape       Code = "E0004"
+	InvalidUTF8         Code = "E0005"
+	InvalidNumber       Code = "E0006"
+	ReadFailure         Code = "E0007"
+)
+
+// Parser errors.
+const (
+	ExpectedToken           Code = "E0100"
+	ExpectedExpression      Code = "E0101"
+	InvalidAssignmentTarget Code = "E0102"
+	TooManyParameters       Code = "E0103"
+	TooManyArguments        Code = "E0104"
+)
+
+// Resolver errors.
+const (
+	DuplicateVariable      Code = "E0200"
+	ReadInOwnInitializer   Code = "E0201"
+	InheritFromSelf        Code = "E0202"
+	TopLevelReturn         Code = "E0203"
+	ReturnFromInitializer  Code = "E0204"
+	SuperOutsideClass      Code = "E0205"
+	SuperWithoutSuperclass Code = "E0206"
+	ThisOutsideClass       Code = "E0207"
+	BreakOutsideLoop       Code = "E0208"
+	ContinueOutsideLoop    Code = "E0209"
+	UndefinedLabel         Code = "E0210"
+)
+
+// RuntimeFailure is the code of a diagnostic describing a *RuntimeError.
+const RuntimeFailure Code = "E0300"
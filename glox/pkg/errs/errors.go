@@ -2,29 +2,182 @@ package errs
 
 import (
 	"fmt"
-	"os"
+	"sort"
+	"strings"
 
 	"github.com/DomBlack/lox/glox/pkg/token"
 )
 
-var (
-	HadError        bool
-	HadRuntimeError bool
+type Severity uint8
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityNote
 )
 
-func ErrorOnLine(line int, message string) {
-	report(line, "", message)
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(strings.ToLower(s.String())), nil
+}
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "Error"
+	case SeverityWarning:
+		return "Warning"
+	case SeverityNote:
+		return "Note"
+	default:
+		return fmt.Sprintf("Severity(%d)", s)
+	}
+}
+
+// Diagnostic is a single problem found while scanning, parsing or resolving a program.
+type Diagnostic struct {
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line"`
+	Column int    `json:"column,omitempty"` // 1-based, or 0 if unknown
+	Start  int    `json:"start"`            // byte offset of the start of the span
+	End    int    `json:"end"`              // byte offset just past the end of the span, or 0 if unknown
+
+	Severity Severity `json:"severity"`
+	Code     Code     `json:"code,omitempty"`
+	Message  string   `json:"message"`
+	Where    string   `json:"-"` // describes the location, such as " at 'x'"
+	Notes    []string `json:"notes,omitempty"`
+}
+
+// SetToken sets the location of the diagnostic to the span of the token.
+func (d *Diagnostic) SetToken(t *token.Token) {
+	position := t.Position()
+	d.File = position.Filename
+	d.Line = t.Line
+	d.Column = position.Column
+
+	if t.File != nil {
+		d.Start = int(t.Start)
+		d.End = int(t.End)
+	}
+}
+
+func (d *Diagnostic) Error() string {
+	return fmt.Sprintf("[line %d] %s %s: %s", d.Line, d.Severity, d.Where, d.Message)
+}
+
+// Diagnostics collects the diagnostics found during a single pass over a program.
+type Diagnostics []*Diagnostic
+
+func (d *Diagnostics) Add(diagnostic *Diagnostic) {
+	*d = append(*d, diagnostic)
 }
 
-func ErrorAtToken(t *token.Token, message string) {
+func (d *Diagnostics) ErrorAtSpan(file *token.File, start, end token.Pos, code Code, message string) {
+	position := file.Position(start)
+	d.Add(&Diagnostic{
+		File:     position.Filename,
+		Line:     position.Line,
+		Column:   position.Column,
+		Start:    int(start),
+		End:      int(end),
+		Severity: SeverityError,
+		Code:     code,
+		Message:  message,
+	})
+}
+
+func (d *Diagnostics) ErrorAtToken(t *token.Token, code Code, message string) {
+	where := " at '" + t.Lexeme + "'"
 	if t.Type == token.EOF {
-		report(t.Line, " at end", message)
-	} else {
-		report(t.Line, " at '"+t.Lexeme+"'", message)
+		where = " at end"
 	}
+
+	diagnostic := &Diagnostic{Severity: SeverityError, Code: code, Message: message, Where: where}
+	diagnostic.SetToken(t)
+	d.Add(diagnostic)
 }
 
-func report(line int, where string, message string) {
-	_, _ = fmt.Fprintf(os.Stderr, "[line %d] Error %s: %s\n", line, where, message)
-	HadError = true
+// HasErrors reports whether any of the diagnostics are errors, rather than warnings or notes.
+func (d Diagnostics) HasErrors() bool {
+	for _, diagnostic := range d {
+		if diagnostic.Severity == SeverityError {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Filter returns the diagnostics for which keep returns true.
+func (d Diagnostics) Filter(keep func(*Diagnostic) bool) Diagnostics {
+	var filtered Diagnostics
+	for _, diagnostic := range d {
+		if keep(diagnostic) {
+			filtered = append(filtered, diagnostic)
+		}
+	}
+
+	return filtered
+}
+
+// Sort orders the diagnostics by file and then by position within the file.
+func (d Diagnostics) Sort() {
+	sort.SliceStable(d, func(i, j int) bool {
+		a, b := d[i], d[j]
+		switch {
+		case a.File != b.File:
+			return a.File < b.File
+		case a.Line != b.Line:
+			return a.Line < b.Line
+		case a.Column != b.Column:
+			return a.Column < b.Column
+		default:
+			return a.Start < b.Start
+		}
+	})
+}
+
+// Dedup sorts the diagnostics and removes all but the first of any which
+// report the same message with the same code at the same position.
+func (d *Diagnostics) Dedup() {
+	d.Sort()
+
+	var deduped Diagnostics
+	for i, diagnostic := range *d {
+		if i > 0 && deduped[len(deduped)-1].duplicates(diagnostic) {
+			continue
+		}
+
+		deduped = append(deduped, diagnostic)
+	}
+
+	*d = deduped
+}
+
+// duplicates reports whether d and other are the same problem, reported twice.
+func (d *Diagnostic) duplicates(other *Diagnostic) bool {
+	return d.File == other.File &&
+		d.Line == other.Line &&
+		d.Column == other.Column &&
+		d.Start == other.Start &&
+		d.Code == other.Code &&
+		d.Message == other.Message
+}
+
+func (d Diagnostics) Error() string {
+	lines := make([]string, len(d))
+	for i, diagnostic := range d {
+		lines[i] = diagnostic.Error()
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Err returns the diagnostics as an error if any of them are errors, otherwise nil.
+func (d Diagnostics) Err() error {
+	if !d.HasErrors() {
+		return nil
+	}
+
+	return d
 }
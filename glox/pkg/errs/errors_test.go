@@ -0,0 +1,73 @@
+package errs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DomBlack/lox/glox/pkg/token"
+)
+
+func TestDiagnostics(t *testing.T) {
+	var diagnostics Diagnostics
+	diagnostics.ErrorAtToken(&token.Token{Type: token.IDENTIFIER, Lexeme: "b", Line: 3}, DuplicateVariable, "Already a variable with this name in this scope.")
+	diagnostics.ErrorAtSpan(token.NewFile(""), 4, 5, UnexpectedCharacter, "Unexpected character.")
+	diagnostics.ErrorAtToken(&token.Token{Type: token.EOF, Line: 3}, DuplicateVariable, "Already a variable with this name in this scope.")
+	diagnostics.Add(&Diagnostic{Line: 2, Severity: SeverityWarning, Message: "Unused variable."})
+
+	if !diagnostics.HasErrors() {
+		t.Error("HasErrors() = false, want true")
+	}
+
+	warnings := diagnostics.Filter(func(d *Diagnostic) bool { return d.Severity == SeverityWarning })
+	if len(warnings) != 1 || warnings.HasErrors() || warnings.Err() != nil {
+		t.Errorf("Filter() = %v, want a single warning", warnings)
+	}
+
+	diagnostics.Dedup()
+	want := "[line 1] Error : Unexpected character.\n" +
+		"[line 2] Warning : Unused variable.\n" +
+		"[line 3] Error  at 'b': Already a variable with this name in this scope."
+	if got := diagnostics.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestDedupKeepsDistinctDiagnostics(t *testing.T) {
+	var diagnostics Diagnostics
+	diagnostics.ErrorAtToken(&token.Token{Type: token.IDENTIFIER, Lexeme: "a", Line: 1, Column: 12}, DuplicateVariable, "Already a variable with this name in this scope.")
+	diagnostics.ErrorAtToken(&token.Token{Type: token.IDENTIFIER, Lexeme: "b", Line: 1, Column: 30}, DuplicateVariable, "Already a variable with this name in this scope.")
+	diagnostics.ErrorAtToken(&token.Token{Type: token.IDENTIFIER, Lexeme: "b", Line: 1, Column: 30}, DuplicateVariable, "Already a variable with this name in this scope.")
+
+	diagnostics.Dedup()
+	want := "[line 1] Error  at 'a': Already a variable with this name in this scope.\n" +
+		"[line 1] Error  at 'b': Already a variable with this name in this scope."
+	if got := diagnostics.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderer(t *testing.T) {
+	source := "var a = 1;\n\tprint a @ 2;\n"
+	diagnostics := Diagnostics{{Line: 2, Start: 20, End: 21, Code: UnexpectedCharacter, Message: "Unexpected character."}}
+
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{FormatPlain, "[line 2] Error : Unexpected character.\n"},
+		{FormatText, "[line 2] Error : Unexpected character.\n   |\n 2 | \tprint a @ 2;\n   | \t        ^\n"},
+		{FormatJSON, `{"line":2,"start":20,"end":21,"severity":"error","code":"E0001","message":"Unexpected character."}` + "\n"},
+	}
+
+	for _, tt := range tests {
+		var out strings.Builder
+		renderer := &Renderer{Format: tt.format, Source: source}
+		if err := renderer.Render(&out, diagnostics); err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+
+		if got := out.String(); got != tt.want {
+			t.Errorf("Render(%d) = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package errs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/DomBlack/lox/glox/pkg/token"
+)
+
+// Format is the way in which a Renderer writes errors.
+type Format uint8
+
+const (
+	// FormatPlain writes each error on its own line, such as "[line 3] Error at 'x': ...".
+	FormatPlain Format = iota
+	// FormatText writes each error followed by the offending source line, with
+	// the span of the error underlined.
+	FormatText
+	// FormatJSON writes each error as a JSON object on its own line, for editors.
+	FormatJSON
+)
+
+// ParseFormat parses the name of a format: "plain", "text" or "json".
+func ParseFormat(name string) (Format, error) {
+	switch name {
+	case "plain":
+		return FormatPlain, nil
+	case "text":
+		return FormatText, nil
+	case "json":
+		return FormatJSON, nil
+	default:
+		return 0, fmt.Errorf("unknown error format %q", name)
+	}
+}
+
+// ANSI escape codes used when rendering with color.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+	ansiBlue   = "\x1b[34m"
+	ansiCyan   = "\x1b[36m"
+)
+
+// Renderer writes errors found in a program.
+type Renderer struct {
+	Format Format
+	Color  bool   // whether to use ANSI colors in FormatText
+	Source string // the program's source code, used to show the offending lines
+}
+
+// Render writes err, which may be Diagnostics, a *Diagnostic, a *RuntimeError
+// or any other error.
+func (r *Renderer) Render(w io.Writer, err error) error {
+	var diagnostics Diagnostics
+	var diagnostic *Diagnostic
+	var runtimeErr *RuntimeError
+
+	switch {
+	case errors.As(err, &diagnostics):
+		for _, diagnostic := range diagnostics {
+			if err := r.render(w, diagnostic, diagnostic.Error(), true); err != nil {
+				return err
+			}
+		}
+		return nil
+	case errors.As(err, &diagnostic):
+		return r.render(w, diagnostic, diagnostic.Error(), true)
+	case errors.As(err, &runtimeErr):
+		// The stack trace is already part of the error's text.
+		return r.render(w, runtimeErr.Diagnostic(), runtimeErr.Error(), false)
+	default:
+		return r.render(w, &Diagnostic{Severity: SeverityError, Message: err.Error()}, err.Error(), true)
+	}
+}
+
+// render writes a single diagnostic, where plain is how it is written in
+// FormatPlain and notes is whether FormatText should add the notes to it.
+func (r *Renderer) render(w io.Writer, d *Diagnostic, plain string, notes bool) error {
+	switch r.Format {
+	case FormatJSON:
+		return json.NewEncoder(w).Encode(d)
+	case FormatText:
+		var builder strings.Builder
+		r.writeHeader(&builder, d, plain)
+		r.writeSnippet(&builder, d)
+		for _, note := range d.Notes {
+			if !notes {
+				break
+			}
+			builder.WriteString(r.color(ansiCyan, "  = note: ") + note + "\n")
+		}
+
+		_, err := io.WriteString(w, builder.String())
+		return err
+	default:
+		_, err := io.WriteString(w, plain+"\n")
+		return err
+	}
+}
+
+func (r *Renderer) writeHeader(builder *strings.Builder, d *Diagnostic, plain string) {
+	if !r.Color {
+		builder.WriteString(plain + "\n")
+		return
+	}
+
+	severityColor := ansiRed
+	if d.Severity != SeverityError {
+		severityColor = ansiYellow
+	}
+
+	// Color the first line of the header, keeping any lines which follow (such
+	// as a stack trace) as they are.
+	first, rest, hasRest := strings.Cut(plain, "\n")
+	if prefix, message, ok := strings.Cut(first, d.Message); ok && message == "" {
+		severity := d.Severity.String()
+		if before, after, ok := strings.Cut(prefix, severity); ok {
+			prefix = before + r.color(severityColor, severity) + after
+		}
+		first = prefix + r.color(ansiBold, d.Message)
+	}
+
+	builder.WriteString(first + "\n")
+	if hasRest {
+		builder.WriteString(rest + "\n")
+	}
+}
+
+// writeSnippet writes the source line containing the diagnostic, underlining
+// its span if it is known.
+func (r *Renderer) writeSnippet(builder *strings.Builder, d *Diagnostic) {
+	lines := strings.Split(r.Source, "\n")
+	if d.Line < 1 || d.Line > len(lines) {
+		return
+	}
+
+	line := strings.TrimRight(lines[d.Line-1], "\r")
+	number := strconv.Itoa(d.Line)
+	gutter := strings.Repeat(" ", len(number))
+
+	if d.File != "" {
+		location := token.Position{Filename: d.File, Line: d.Line, Column: d.Column}
+		builder.WriteString(r.color(ansiBlue, gutter+"--> ") + location.String() + "\n")
+	}
+	builder.WriteString(r.color(ansiBlue, " "+gutter+" |") + "\n")
+	builder.WriteString(r.color(ansiBlue, " "+number+" |") + " " + line + "\n")
+
+	lineStart := lineOffset(r.Source, d.Line)
+	if d.End <= d.Start || d.Start < lineStart || d.Start > lineStart+len(line) {
+		return
+	}
+
+	// Indent the caret with the same whitespace as the source line, so tabs
+	// still line up, and underline the part of the span on this line.
+	var indent strings.Builder
+	for _, c := range line[:d.Start-lineStart] {
+		if c == '\t' {
+			indent.WriteRune('\t')
+		} else {
+			indent.WriteRune(' ')
+		}
+	}
+
+	end := d.End - lineStart
+	if end > len(line) {
+		end = len(line)
+	}
+	width := utf8.RuneCountInString(line[d.Start-lineStart : end])
+	if width < 1 {
+		width = 1
+	}
+
+	builder.WriteString(r.color(ansiBlue, " "+gutter+" |") + " " + indent.String() + r.color(ansiRed, strings.Repeat("^", width)) + "\n")
+}
+
+func (r *Renderer) color(code string, text string) string {
+	if !r.Color {
+		return text
+	}
+
+	return code + text + ansiReset
+}
+
+// lineOffset returns the byte offset of the start of the given 1-based line.
+func lineOffset(source string, line int) int {
+	offset := 0
+	for i := 1; i < line; i++ {
+		next := strings.IndexByte(source[offset:], '\n')
+		if next < 0 {
+			return len(source)
+		}
+		offset += next + 1
+	}
+
+	return offset
+}
@@ -2,17 +2,83 @@ package errs
 
 import (
 	"fmt"
-	"os"
+	"strings"
 
 	"github.com/DomBlack/lox/glox/pkg/token"
 )
 
+// RuntimeError is raised while a program is running. Token is nil if the error
+// did not occur at a specific token, such as when calling a non-function from
+// Go. Err is the underlying cause, if any, such as a context error.
 type RuntimeError struct {
 	Token *token.Token
 	Msg   string
+	Err   error
+	Trace []Frame // the Lox call stack when the error was raised, innermost first
 }
 
-func ErrorAtRuntime(e *RuntimeError) {
-	fmt.Fprintf(os.Stderr, "%s\n[line %d]\n", e.Msg, e.Token.Line)
-	HadRuntimeError = true
+// Frame is a single entry in the stack trace of a runtime error.
+type Frame struct {
+	Function string // empty for top-level code
+	Class    string // the class the function is a method of, if any
+	Line     int
+}
+
+func (f Frame) String() string {
+	switch {
+	case f.Function == "":
+		return fmt.Sprintf("[line %d] in script", f.Line)
+	case f.Class != "":
+		return fmt.Sprintf("[line %d] in %s.%s()", f.Line, f.Class, f.Function)
+	default:
+		return fmt.Sprintf("[line %d] in %s()", f.Line, f.Function)
+	}
+}
+
+// maxTraceFrames is the number of frames rendered before the middle of a long
+// stack trace, such as from a stack overflow, is elided.
+const maxTraceFrames = 16
+
+func (e *RuntimeError) Error() string {
+	switch {
+	case len(e.Trace) > 0:
+		var builder strings.Builder
+		builder.WriteString(e.Msg)
+
+		for i, frame := range e.Trace {
+			if len(e.Trace) > maxTraceFrames && i >= maxTraceFrames/2 && i < len(e.Trace)-maxTraceFrames/2 {
+				if i == maxTraceFrames/2 {
+					_, _ = fmt.Fprintf(&builder, "\n... %d more frames ...", len(e.Trace)-maxTraceFrames)
+				}
+				continue
+			}
+
+			builder.WriteString("\n")
+			builder.WriteString(frame.String())
+		}
+
+		return builder.String()
+	case e.Token != nil:
+		return fmt.Sprintf("%s\n[line %d]", e.Msg, e.Token.Line)
+	default:
+		return e.Msg
+	}
+}
+
+func (e *RuntimeError) Unwrap() error {
+	return e.Err
+}
+
+// Diagnostic describes the error as a diagnostic, with the stack trace as notes.
+func (e *RuntimeError) Diagnostic() *Diagnostic {
+	diagnostic := &Diagnostic{Severity: SeverityError, Code: RuntimeFailure, Message: e.Msg}
+	if e.Token != nil {
+		diagnostic.SetToken(e.Token)
+	}
+
+	for _, frame := range e.Trace {
+		diagnostic.Notes = append(diagnostic.Notes, frame.String())
+	}
+
+	return diagnostic
 }
@@ -32,12 +32,12 @@ func (c *Class) Arity() int {
 	return initializer.Arity()
 }
 
-func (c *Class) Call(interpreter *interpreter, arguments []any) any {
-	instance := &Instance{Class: c}
+func (c *Class) Call(interpreter *interpreter, paren *token.Token, arguments []any) any {
+	instance := &Instance{Class: c, Fields: make(map[string]any)}
 
 	initializer := c.findMethod("init")
 	if initializer != nil {
-		initializer.Bind(instance).Call(interpreter, arguments)
+		initializer.Bind(instance).Call(interpreter, paren, arguments)
 	}
 
 	return instance
@@ -65,15 +65,23 @@ type Instance struct {
 }
 
 func (i *Instance) Get(name *token.Token) any {
-	if value, ok := i.Fields[name.Lexeme]; ok {
+	if value, ok := i.get(name.Lexeme); ok {
 		return value
 	}
 
-	if method := i.Class.findMethod(name.Lexeme); method != nil {
-		return method.Bind(i)
+	panic(&errs.RuntimeError{Token: name, Msg: fmt.Sprintf("Undefined property '%s'.", name.Lexeme)})
+}
+
+func (i *Instance) get(name string) (any, bool) {
+	if value, ok := i.Fields[name]; ok {
+		return value, true
 	}
 
-	panic(&errs.RuntimeError{Token: name, Msg: fmt.Sprintf("Undefined property '%s'.", name.Lexeme)})
+	if method := i.Class.findMethod(name); method != nil {
+		return method.Bind(i), true
+	}
+
+	return nil, false
 }
 
 func (i *Instance) Set(name *token.Token, value any) {
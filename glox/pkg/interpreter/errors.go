@@ -1,6 +1,8 @@
 package interpreter
 
 import (
+	"math"
+
 	"github.com/DomBlack/lox/glox/pkg/errs"
 	"github.com/DomBlack/lox/glox/pkg/token"
 )
@@ -12,3 +14,25 @@ func checkNumberOperands(operator *token.Token, operand ...any) {
 		}
 	}
 }
+
+// checkIntegerOperand checks the operand of a bitwise operator is an integral
+// number which fits in an int64, and returns it as one.
+func checkIntegerOperand(operator *token.Token, operand any) int64 {
+	checkNumberOperands(operator, operand)
+
+	n := operand.(float64)
+	if n != math.Trunc(n) || n < math.MinInt64 || n >= math.MaxInt64 {
+		panic(&errs.RuntimeError{Token: operator, Msg: "Operand must be an integer."})
+	}
+	return int64(n)
+}
+
+func checkIntegerOperands(operator *token.Token, left, right any) (int64, int64) {
+	return checkIntegerOperand(operator, left), checkIntegerOperand(operator, right)
+}
+
+func checkShiftCount(operator *token.Token, count int64) {
+	if count < 0 {
+		panic(&errs.RuntimeError{Token: operator, Msg: "Shift count must not be negative."})
+	}
+}
@@ -1,7 +1,8 @@
 package interpreter
 
 import (
-	"fmt"
+	"math"
+	"strings"
 
 	"github.com/DomBlack/lox/glox/pkg/ast"
 	"github.com/DomBlack/lox/glox/pkg/errs"
@@ -15,18 +16,49 @@ func (i *interpreter) evaluate(expr ast.Expr) any {
 }
 
 func (i *interpreter) VisitAssignExpr(v *ast.AssignExpr) any {
-	value := i.evaluate(v.Value)
+	operator, compound := compoundOperator(v.Operator)
+
+	var current any
+	if compound {
+		current = i.lookupVariable(v.Name, v)
+	}
 
-	distance, ok := i.locals[v]
-	if ok {
-		i.environment.AssignAt(distance, v.Name, value)
-	} else {
-		globals.Assign(v.Name, value)
+	value := i.evaluate(v.Value)
+	if compound {
+		value = arithmetic(operator, v.Operator, current, value)
 	}
 
+	i.assignVariable(v.Name, v, value)
 	return value
 }
 
+// compoundOperator returns the arithmetic operator of a compound assignment
+// operator such as "+=", or false for a plain assignment.
+func compoundOperator(operator *token.Token) (token.Type, bool) {
+	if operator == nil {
+		return 0, false
+	}
+
+	switch operator.Type {
+	case token.PLUS_EQUAL:
+		return token.PLUS, true
+	case token.MINUS_EQUAL:
+		return token.MINUS, true
+	case token.STAR_EQUAL:
+		return token.STAR, true
+	case token.SLASH_EQUAL:
+		return token.SLASH, true
+	case token.PERCENT_EQUAL:
+		return token.PERCENT, true
+	default:
+		return 0, false
+	}
+}
+
+func (i *interpreter) VisitBadExpr(v *ast.BadExpr) any {
+	panic(&errs.RuntimeError{Token: v.From, Msg: "Cannot run code with syntax errors."})
+}
+
 func (i *interpreter) VisitBinaryExpr(v *ast.BinaryExpr) any {
 	left := i.evaluate(v.Left)
 	right := i.evaluate(v.Right)
@@ -48,15 +80,50 @@ func (i *interpreter) VisitBinaryExpr(v *ast.BinaryExpr) any {
 		return !isEqual(left, right)
 	case token.EQUAL_EQUAL:
 		return isEqual(left, right)
+	default:
+		return arithmetic(v.Operator.Type, v.Operator, left, right)
+	}
+}
+
+// arithmetic applies an arithmetic operator, which is shared between binary
+// expressions and compound assignments. Errors are reported at the token at.
+func arithmetic(operator token.Type, at *token.Token, left, right any) any {
+	switch operator {
 	case token.MINUS:
-		checkNumberOperands(v.Operator, left, right)
+		checkNumberOperands(at, left, right)
 		return left.(float64) - right.(float64)
 	case token.SLASH:
-		checkNumberOperands(v.Operator, left, right)
+		checkNumberOperands(at, left, right)
 		return left.(float64) / right.(float64)
 	case token.STAR:
-		checkNumberOperands(v.Operator, left, right)
+		checkNumberOperands(at, left, right)
 		return left.(float64) * right.(float64)
+	case token.PERCENT:
+		checkNumberOperands(at, left, right)
+		return math.Mod(left.(float64), right.(float64))
+	case token.TILDE_SLASH:
+		checkNumberOperands(at, left, right)
+		return math.Floor(left.(float64) / right.(float64))
+	case token.STAR_STAR:
+		checkNumberOperands(at, left, right)
+		return math.Pow(left.(float64), right.(float64))
+	case token.AMPERSAND:
+		l, r := checkIntegerOperands(at, left, right)
+		return float64(l & r)
+	case token.PIPE:
+		l, r := checkIntegerOperands(at, left, right)
+		return float64(l | r)
+	case token.CARET:
+		l, r := checkIntegerOperands(at, left, right)
+		return float64(l ^ r)
+	case token.LESS_LESS:
+		l, r := checkIntegerOperands(at, left, right)
+		checkShiftCount(at, r)
+		return float64(l << r)
+	case token.GREATER_GREATER:
+		l, r := checkIntegerOperands(at, left, right)
+		checkShiftCount(at, r)
+		return float64(l >> r)
 	case token.PLUS:
 		if left, ok := left.(float64); ok {
 			if right, ok := right.(float64); ok {
@@ -70,21 +137,105 @@ func (i *interpreter) VisitBinaryExpr(v *ast.BinaryExpr) any {
 			}
 		}
 
-		panic(&errs.RuntimeError{Token: v.Operator, Msg: "Operands must be two numbers or two strings."})
+		panic(&errs.RuntimeError{Token: at, Msg: "Operands must be two numbers or two strings."})
 	}
 
 	return nil
 }
 
+func (i *interpreter) VisitFunctionExpr(v *ast.FunctionExpr) any {
+	return &Function{params: v.Params, body: v.Body, closure: i.environment}
+}
+
+func (i *interpreter) VisitConditionalExpr(v *ast.ConditionalExpr) any {
+	if isTruthy(i.evaluate(v.Condition)) {
+		return i.evaluate(v.ThenBranch)
+	}
+	return i.evaluate(v.ElseBranch)
+}
+
 func (i *interpreter) VisitGetExpr(v *ast.GetExpr) any {
 	object := i.evaluate(v.Object)
-	if object, ok := object.(*Instance); ok {
+	if object == nil && v.Optional {
+		panic(shortCircuit{})
+	}
+	if object, ok := object.(Object); ok {
 		return object.Get(v.Name)
 	}
 
 	panic(&errs.RuntimeError{Token: v.Name, Msg: "Only instances have properties."})
 }
 
+func (i *interpreter) VisitIndexExpr(v *ast.IndexExpr) any {
+	object := i.indexable(v.Object, v.LeftBracket)
+	return object.Index(v.LeftBracket, i.evaluate(v.Index))
+}
+
+func (i *interpreter) VisitIndexSetExpr(v *ast.IndexSetExpr) any {
+	object := i.indexable(v.Object, v.LeftBracket)
+	index := i.evaluate(v.Index)
+
+	operator, compound := compoundOperator(v.Operator)
+
+	var current any
+	if compound {
+		current = object.Index(v.LeftBracket, index)
+	}
+
+	value := i.evaluate(v.Value)
+	if compound {
+		value = arithmetic(operator, v.Operator, current, value)
+	}
+
+	object.SetIndex(v.LeftBracket, index, value)
+	return value
+}
+
+// indexable evaluates the object of an index expression, which must be
+// Indexable. Errors are reported at the opening bracket.
+func (i *interpreter) indexable(expr ast.Expr, bracket *token.Token) Indexable {
+	if object, ok := i.evaluate(expr).(Indexable); ok {
+		return object
+	}
+
+	panic(&errs.RuntimeError{Token: bracket, Msg: "Only lists and maps can be indexed."})
+}
+
+func (i *interpreter) VisitSliceExpr(v *ast.SliceExpr) any {
+	list, ok := i.evaluate(v.Object).(*List)
+	if !ok {
+		panic(&errs.RuntimeError{Token: v.LeftBracket, Msg: "Only lists can be sliced."})
+	}
+
+	var low, high any
+	if v.Low != nil {
+		low = i.evaluate(v.Low)
+	}
+	if v.High != nil {
+		high = i.evaluate(v.High)
+	}
+
+	return list.Slice(v.LeftBracket, low, high)
+}
+
+func (i *interpreter) VisitListExpr(v *ast.ListExpr) any {
+	elements := make([]any, len(v.Elements))
+	for j, element := range v.Elements {
+		elements[j] = i.evaluate(element)
+	}
+	return NewList(elements...)
+}
+
+func (i *interpreter) VisitMapExpr(v *ast.MapExpr) any {
+	m := NewMap()
+	for j, key := range v.Keys {
+		if err := m.Store(i.evaluate(key), i.evaluate(v.Values[j])); err != nil {
+			panic(&errs.RuntimeError{Token: v.LeftBrace, Msg: "Invalid map key.", Err: err})
+		}
+	}
+	return m
+}
+
 func (i *interpreter) VisitGroupingExpr(v *ast.GroupingExpr) any {
 	return i.evaluate(v.Expression)
 }
@@ -92,11 +243,16 @@ func (i *interpreter) VisitGroupingExpr(v *ast.GroupingExpr) any {
 func (i *interpreter) VisitLogicalExpr(v *ast.LogicalExpr) any {
 	left := i.evaluate(v.Left)
 
-	if v.Operator.Type == token.OR {
+	switch v.Operator.Type {
+	case token.OR:
 		if isTruthy(left) {
 			return left
 		}
-	} else {
+	case token.QUESTION_QUESTION:
+		if left != nil {
+			return left
+		}
+	default:
 		if !isTruthy(left) {
 			return left
 		}
@@ -105,6 +261,37 @@ func (i *interpreter) VisitLogicalExpr(v *ast.LogicalExpr) any {
 	return i.evaluate(v.Right)
 }
 
+// shortCircuit is panicked by an optional property access on nil, to unwind
+// to the enclosing OptionalChainExpr.
+type shortCircuit struct{}
+
+func (i *interpreter) VisitOptionalChainExpr(v *ast.OptionalChainExpr) (value any) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(shortCircuit); !ok {
+				panic(r)
+			}
+			value = nil
+		}
+	}()
+
+	return i.evaluate(v.Expression)
+}
+
+func (i *interpreter) VisitInterpolationExpr(v *ast.InterpolationExpr) any {
+	var builder strings.Builder
+	for _, part := range v.Parts {
+		// Strings are embedded as they are, rather than quoted as print would.
+		value := i.evaluate(part)
+		if str, ok := value.(string); ok {
+			builder.WriteString(str)
+		} else {
+			builder.WriteString(stringify(value))
+		}
+	}
+	return builder.String()
+}
+
 func (i *interpreter) VisitLiteralExpr(v *ast.LiteralExpr) any {
 	return v.Value
 }
@@ -117,30 +304,79 @@ func (i *interpreter) VisitCallExpr(v *ast.CallExpr) any {
 		args = append(args, i.evaluate(arg))
 	}
 
-	if function, ok := callee.(Callable); ok {
-		if len(args) != function.Arity() {
-			panic(&errs.RuntimeError{Token: v.Paren, Msg: fmt.Sprintf("Expected %d arguments but got %d.", function.Arity(), len(args))})
-		}
-
-		return function.Call(i, args)
-	}
-
-	panic(&errs.RuntimeError{Token: v.Paren, Msg: "Can only call functions and classes."})
+	return i.call(callee, v.Paren, args)
 }
 
 func (i *interpreter) VisitSetExpr(v *ast.SetExpr) any {
 	object := i.evaluate(v.Object)
 
-	instance, ok := object.(*Instance)
+	instance, ok := object.(Object)
 	if !ok {
 		panic(&errs.RuntimeError{Token: v.Name, Msg: "Only instances have fields."})
 	}
 
+	operator, compound := compoundOperator(v.Operator)
+
+	var current any
+	if compound {
+		current = instance.Get(v.Name)
+	}
+
 	value := i.evaluate(v.Value)
+	if compound {
+		value = arithmetic(operator, v.Operator, current, value)
+	}
+
 	instance.Set(v.Name, value)
 	return value
 }
 
+func (i *interpreter) VisitUpdateExpr(v *ast.UpdateExpr) any {
+	operator, prefix := v.Prefix, true
+	if operator == nil {
+		operator, prefix = v.Postfix, false
+	}
+
+	delta := 1.0
+	if operator.Type == token.MINUS_MINUS {
+		delta = -1
+	}
+
+	var old, updated any
+	switch target := v.Target.(type) {
+	case *ast.VariableExpr:
+		old = i.lookupVariable(target.Name, target)
+		checkNumberOperands(operator, old)
+		updated = old.(float64) + delta
+		i.assignVariable(target.Name, target, updated)
+
+	case *ast.GetExpr:
+		object, ok := i.evaluate(target.Object).(Object)
+		if !ok {
+			panic(&errs.RuntimeError{Token: target.Name, Msg: "Only instances have fields."})
+		}
+
+		old = object.Get(target.Name)
+		checkNumberOperands(operator, old)
+		updated = old.(float64) + delta
+		object.Set(target.Name, updated)
+
+	case *ast.IndexExpr:
+		object := i.indexable(target.Object, target.LeftBracket)
+		index := i.evaluate(target.Index)
+
+		old = object.Index(target.LeftBracket, index)
+		checkNumberOperands(operator, old)
+		updated = old.(float64) + delta
+		object.SetIndex(target.LeftBracket, index, updated)
+	}
+
+	if prefix {
+		return updated
+	}
+	return old
+}
+
 func (i *interpreter) VisitSuperExpr(v *ast.SuperExpr) any {
 	distance := i.locals[v]
 	superclass := i.environment.GetAt(distance, "super").(*Class)
@@ -168,6 +404,8 @@ func (i *interpreter) VisitUnaryExpr(v *ast.UnaryExpr) any {
 	case token.MINUS:
 		checkNumberOperands(v.Operator, right)
 		return -right.(float64)
+	case token.TILDE:
+		return float64(^checkIntegerOperand(v.Operator, right))
 	}
 
 	return nil
@@ -4,6 +4,8 @@ import (
 	"fmt"
 
 	"github.com/DomBlack/lox/glox/pkg/ast"
+	"github.com/DomBlack/lox/glox/pkg/errs"
+	"github.com/DomBlack/lox/glox/pkg/token"
 )
 
 type FunctionType uint8
@@ -16,20 +18,30 @@ const (
 )
 
 type Function struct {
-	declaration   *ast.FunctionStmt
+	name          string // empty for an anonymous function
+	params        []*token.Token
+	body          []ast.Stmt
 	closure       *Environment
 	isInitializer bool
+	class         *Class // the class this is a method of, if any
 }
 
 var _ Callable = (*Function)(nil)
 
 func (f *Function) Arity() int {
-	return len(f.declaration.Params)
+	return len(f.params)
 }
 
-func (f *Function) Call(interpreter *interpreter, arguments []any) (rtn any) {
+func (f *Function) Call(interpreter *interpreter, paren *token.Token, arguments []any) (rtn any) {
+	interpreter.pushFrame(f, paren)
 	defer func() {
-		if r := recover(); r != nil {
+		r := recover()
+		if e, ok := r.(*errs.RuntimeError); ok {
+			interpreter.captureTrace(e)
+		}
+		interpreter.popFrame()
+
+		if r != nil {
 			if rtnValue, ok := r.(*Return); ok {
 				if f.isInitializer {
 					rtn = f.closure.GetAt(0, "this")
@@ -43,11 +55,11 @@ func (f *Function) Call(interpreter *interpreter, arguments []any) (rtn any) {
 	}()
 
 	env := f.closure.Scope()
-	for i, param := range f.declaration.Params {
+	for i, param := range f.params {
 		env.Define(param.Lexeme, arguments[i])
 	}
 
-	interpreter.executeBlock(f.declaration.Body, env)
+	interpreter.executeBlock(f.body, env)
 
 	if f.isInitializer {
 		return f.closure.GetAt(0, "this")
@@ -59,9 +71,19 @@ func (f *Function) Call(interpreter *interpreter, arguments []any) (rtn any) {
 func (f *Function) Bind(instance *Instance) *Function {
 	env := f.closure.Scope()
 	env.Define("this", instance)
-	return &Function{f.declaration, env, f.isInitializer}
+	bound := *f
+	bound.closure = env
+	return &bound
+}
+
+// Name returns the name of the function, or "anonymous" if it has none.
+func (f *Function) Name() string {
+	if f.name == "" {
+		return "anonymous"
+	}
+	return f.name
 }
 
 func (f *Function) String() string {
-	return fmt.Sprintf("<fn %s>", f.declaration.Name.Lexeme)
+	return fmt.Sprintf("<fn %s>", f.Name())
 }
@@ -1,16 +1,32 @@
 package interpreter
 
 import (
+	"errors"
 	"time"
+	"unicode/utf8"
 )
 
-var globals = NewEnvironment()
+// newGlobals creates the global scope for a new interpreter, seeded with the
+// native functions.
+func newGlobals() *Environment {
+	globals := NewEnvironment()
 
-func init() {
-	globals.Define("clock", &CallableFunc{
-		arity: 0,
-		fn: func(interpreter Interpreter, arguments []any) any {
-			return float64(time.Now().UnixNano()) / 1e9
-		},
-	})
+	globals.Define("clock", NewNative("clock", 0, func(_ Args) (any, error) {
+		return float64(time.Now().UnixNano()) / 1e9, nil
+	}))
+
+	globals.Define("len", NewNative("len", 1, func(args Args) (any, error) {
+		switch value := args[0].(type) {
+		case *List:
+			return float64(len(value.Elements())), nil
+		case *Map:
+			return float64(value.Len()), nil
+		case string:
+			return float64(utf8.RuneCountInString(value)), nil
+		default:
+			return nil, errors.New("Argument 1 must be a list, map or string.")
+		}
+	}))
+
+	return globals
 }
@@ -0,0 +1,297 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/DomBlack/lox/glox/pkg/errs"
+	"github.com/DomBlack/lox/glox/pkg/token"
+)
+
+// GoInstance exposes a Go struct to Lox as an instance. Its properties are the
+// exported fields of the struct, which can be renamed with a `lox:"name"` tag,
+// and its methods are the exported methods of the struct. Values are converted
+// between Lox and Go types as they cross the boundary.
+type GoInstance struct {
+	value reflect.Value // pointer to the struct
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// NewGoInstance wraps v, which must be a struct or a pointer to a struct. If v
+// is a struct it is copied, so changes made by Lox code will not be visible in v.
+func NewGoInstance(v any) (*GoInstance, error) {
+	value := reflect.ValueOf(v)
+	switch {
+	case value.Kind() == reflect.Pointer && !value.IsNil() && value.Elem().Kind() == reflect.Struct:
+		return &GoInstance{value}, nil
+	case value.Kind() == reflect.Struct:
+		ptr := reflect.New(value.Type())
+		ptr.Elem().Set(value)
+		return &GoInstance{ptr}, nil
+	default:
+		return nil, fmt.Errorf("cannot bind %T: must be a struct or a pointer to a struct", v)
+	}
+}
+
+// Value returns the pointer to the wrapped struct.
+func (g *GoInstance) Value() any {
+	return g.value.Interface()
+}
+
+func (g *GoInstance) Get(name *token.Token) any {
+	if field, ok := g.field(name.Lexeme); ok {
+		return fromGo(field)
+	}
+
+	if method := g.value.MethodByName(name.Lexeme); method.IsValid() {
+		return &goMethod{name, method}
+	}
+
+	panic(&errs.RuntimeError{Token: name, Msg: fmt.Sprintf("Undefined property '%s'.", name.Lexeme)})
+}
+
+func (g *GoInstance) Set(name *token.Token, value any) {
+	field, ok := g.field(name.Lexeme)
+	if !ok {
+		panic(&errs.RuntimeError{Token: name, Msg: fmt.Sprintf("Undefined property '%s'.", name.Lexeme)})
+	}
+
+	converted, err := toGo(value, field.Type())
+	if err != nil {
+		panic(&errs.RuntimeError{Token: name, Msg: fmt.Sprintf("Cannot set '%s': %s.", name.Lexeme, err)})
+	}
+
+	field.Set(converted)
+}
+
+func (g *GoInstance) field(name string) (reflect.Value, bool) {
+	structValue := g.value.Elem()
+	structType := structValue.Type()
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldName := field.Name
+		if tag, ok := field.Tag.Lookup("lox"); ok {
+			fieldName = tag
+		}
+
+		if fieldName == name {
+			return structValue.Field(i), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+func (g *GoInstance) String() string {
+	return fmt.Sprintf("%s instance", g.value.Elem().Type().Name())
+}
+
+// goMethod is a method of a GoInstance, bound to its receiver.
+type goMethod struct {
+	name   *token.Token
+	method reflect.Value
+}
+
+var _ Callable = (*goMethod)(nil)
+
+func (m *goMethod) Arity() int {
+	if m.method.Type().IsVariadic() {
+		return Variadic
+	}
+
+	return m.method.Type().NumIn()
+}
+
+func (m *goMethod) Call(_ *interpreter, paren *token.Token, arguments []any) any {
+	methodType := m.method.Type()
+	if methodType.IsVariadic() && len(arguments) < methodType.NumIn()-1 {
+		panic(&errs.RuntimeError{Token: paren, Msg: fmt.Sprintf("Expected at least %d arguments but got %d.", methodType.NumIn()-1, len(arguments))})
+	}
+
+	args := make([]reflect.Value, len(arguments))
+	for i, argument := range arguments {
+		var paramType reflect.Type
+		if methodType.IsVariadic() && i >= methodType.NumIn()-1 {
+			paramType = methodType.In(methodType.NumIn() - 1).Elem()
+		} else {
+			paramType = methodType.In(i)
+		}
+
+		arg, err := toGo(argument, paramType)
+		if err != nil {
+			panic(&errs.RuntimeError{Token: m.name, Msg: fmt.Sprintf("Argument %d to '%s' %s.", i+1, m.name.Lexeme, err)})
+		}
+		args[i] = arg
+	}
+
+	results := m.method.Call(args)
+	if n := len(results); n > 0 && methodType.Out(n-1) == errorType {
+		if err, _ := results[n-1].Interface().(error); err != nil {
+			panic(&errs.RuntimeError{Token: paren, Msg: err.Error()})
+		}
+		results = results[:n-1]
+	}
+
+	switch len(results) {
+	case 0:
+		return nil
+	case 1:
+		return fromGo(results[0])
+	default:
+		panic(&errs.RuntimeError{Token: m.name, Msg: fmt.Sprintf("Method '%s' returns more than one value.", m.name.Lexeme)})
+	}
+}
+
+func (m *goMethod) String() string {
+	return fmt.Sprintf("<native fn %s>", m.name.Lexeme)
+}
+
+// fromGo converts a Go value into a Lox value.
+func fromGo(value reflect.Value) any {
+	switch value.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Bool:
+		return value.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		return value.Float()
+	case reflect.String:
+		return value.String()
+	case reflect.Interface:
+		if value.IsNil() {
+			return nil
+		}
+		return fromGo(value.Elem())
+	case reflect.Pointer:
+		if value.IsNil() {
+			return nil
+		}
+		if value.Elem().Kind() == reflect.Struct {
+			return &GoInstance{value}
+		}
+	case reflect.Struct:
+		if value.CanAddr() {
+			return &GoInstance{value.Addr()}
+		}
+		instance, _ := NewGoInstance(value.Interface())
+		return instance
+	}
+
+	return value.Interface()
+}
+
+// toLox converts a value passed in from Go into a Lox value, so that a Go int
+// becomes a number. Values which are already Lox values, such as functions
+// and instances, are returned as they are.
+func toLox(value any) any {
+	switch value.(type) {
+	case nil, bool, float64, string, Callable, Object:
+		return value
+	}
+
+	return fromGo(reflect.ValueOf(value))
+}
+
+// fitsInt reports whether value is an integer which fits in a signed integer
+// of the given size. The bounds are checked on the float64, as converting an
+// out of range float64 to an integer gives an implementation-defined result.
+func fitsInt(value float64, bits int) bool {
+	if math.IsNaN(value) || math.IsInf(value, 0) || value != math.Trunc(value) {
+		return false
+	}
+
+	limit := math.Ldexp(1, bits-1)
+	return value >= -limit && value < limit
+}
+
+// fitsUint reports whether value is an integer which fits in an unsigned
+// integer of the given size.
+func fitsUint(value float64, bits int) bool {
+	if math.IsNaN(value) || math.IsInf(value, 0) || value != math.Trunc(value) {
+		return false
+	}
+
+	return value >= 0 && value < math.Ldexp(1, bits)
+}
+
+// toGo converts a Lox value into a Go value of the given type.
+func toGo(value any, to reflect.Type) (reflect.Value, error) {
+	if instance, ok := value.(*GoInstance); ok {
+		switch {
+		case instance.value.Type().AssignableTo(to):
+			return instance.value, nil
+		case instance.value.Elem().Type().AssignableTo(to):
+			return instance.value.Elem(), nil
+		}
+	}
+
+	switch value := value.(type) {
+	case nil:
+		switch to.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map, reflect.Func, reflect.Chan:
+			return reflect.Zero(to), nil
+		}
+
+	case float64:
+		switch to.Kind() {
+		case reflect.Float32, reflect.Float64:
+			return reflect.ValueOf(value).Convert(to), nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if !fitsInt(value, to.Bits()) {
+				return reflect.Value{}, fmt.Errorf("must be an integer that fits in %s", to)
+			}
+			return reflect.ValueOf(int64(value)).Convert(to), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if !fitsUint(value, to.Bits()) {
+				return reflect.Value{}, fmt.Errorf("must be a non-negative integer that fits in %s", to)
+			}
+			return reflect.ValueOf(uint64(value)).Convert(to), nil
+		}
+
+	case string:
+		if to.Kind() == reflect.String {
+			return reflect.ValueOf(value).Convert(to), nil
+		}
+
+	case bool:
+		if to.Kind() == reflect.Bool {
+			return reflect.ValueOf(value).Convert(to), nil
+		}
+	}
+
+	if value != nil && reflect.TypeOf(value).AssignableTo(to) {
+		return reflect.ValueOf(value), nil
+	}
+
+	return reflect.Value{}, errors.New("must be " + describeType(to))
+}
+
+// describeType describes a Go type in terms of the Lox values that convert to it.
+func describeType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.String:
+		return "a string"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return "an integer"
+	default:
+		return "a " + strings.TrimPrefix(t.String(), "*")
+	}
+}
@@ -1,7 +1,11 @@
 package interpreter
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"os"
+	"time"
 
 	"github.com/DomBlack/lox/glox/pkg/ast"
 	"github.com/DomBlack/lox/glox/pkg/errs"
@@ -9,41 +13,144 @@ import (
 )
 
 type interpreter struct {
+	globals     *Environment
 	environment *Environment
 	locals      map[ast.Expr]int
+	stdout      io.Writer
+	frames      []frame
+
+	ctx      context.Context
+	running  bool // whether a call to run is in progress
+	steps    int
+	depth    int
+	maxSteps int
+	maxDepth int
+	timeout  time.Duration
 }
 
 type Interpreter interface {
-	Resolve(stmts []ast.Stmt)
-	Interpret(stmts []ast.Stmt)
+	Resolve(stmts []ast.Stmt) errs.Diagnostics
+	// Interpret executes the statements, stopping with a runtime error if ctx
+	// is done, a budget set by WithMaxSteps or WithTimeout is exceeded, or the
+	// stack overflows.
+	Interpret(ctx context.Context, stmts []ast.Stmt) error
+
+	// Define sets a global variable, such as a *Native function.
+	Define(name string, value any)
+
+	// Get returns the value of a global variable, and whether it is defined.
+	Get(name string) (any, bool)
+
+	// Call calls a Lox function, class or native function with the given
+	// arguments, returning any runtime error raised by it. Go arguments are
+	// converted to Lox values as for a GoInstance, so an int becomes a number.
+	Call(ctx context.Context, callee any, args ...any) (any, error)
+
+	// Invoke calls the named method of an instance with the given arguments,
+	// returning any runtime error raised by it.
+	Invoke(ctx context.Context, instance *Instance, method string, args ...any) (any, error)
 }
 
-func New() Interpreter {
-	return &interpreter{
+// Option configures an interpreter created by New.
+type Option func(i *interpreter)
+
+// WithStdout sets the writer that print statements write to, by default os.Stdout.
+func WithStdout(w io.Writer) Option {
+	return func(i *interpreter) {
+		i.stdout = w
+	}
+}
+
+func New(opts ...Option) Interpreter {
+	globals := newGlobals()
+	i := &interpreter{
+		globals:     globals,
 		environment: globals,
 		locals:      make(map[ast.Expr]int),
+		stdout:      os.Stdout,
+		ctx:         context.Background(),
+		maxDepth:    DefaultMaxCallDepth,
 	}
+
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	return i
 }
 
-func (i *interpreter) Interpret(stmts []ast.Stmt) {
-	defer func() {
-		if r := recover(); r != nil {
-			if e, ok := r.(*errs.RuntimeError); ok {
-				errs.ErrorAtRuntime(e)
-			} else {
-				panic(fmt.Sprintf("Unhandled Panic (%T): %v", e, e))
-			}
+func (i *interpreter) Interpret(ctx context.Context, stmts []ast.Stmt) error {
+	return i.run(ctx, func() {
+		for _, stmt := range stmts {
+			i.execute(stmt)
 		}
-	}()
+	})
+}
+
+func (i *interpreter) Define(name string, value any) {
+	i.globals.Define(name, value)
+}
 
-	for _, stmt := range stmts {
-		i.execute(stmt)
+func (i *interpreter) Get(name string) (any, bool) {
+	value, ok := i.globals.Values[name]
+	return value, ok
+}
+
+func (i *interpreter) Call(ctx context.Context, callee any, args ...any) (rtn any, err error) {
+	converted := make([]any, len(args))
+	for j, arg := range args {
+		converted[j] = toLox(arg)
 	}
+
+	err = i.run(ctx, func() {
+		rtn = toLox(i.call(callee, nil, converted))
+	})
+
+	return rtn, err
 }
 
-func (i *interpreter) Resolve(stmts []ast.Stmt) {
-	resolver := &resolver{i, nil, FTNone, CTNone}
+func (i *interpreter) Invoke(ctx context.Context, instance *Instance, method string, args ...any) (any, error) {
+	callee, ok := instance.get(method)
+	if !ok {
+		return nil, &errs.RuntimeError{Msg: fmt.Sprintf("Undefined property '%s'.", method)}
+	}
+
+	return i.Call(ctx, callee, args...)
+}
+
+// call calls the callee with the given arguments, where paren is the closing
+// parenthesis of the call expression, or nil if called from Go.
+func (i *interpreter) call(callee any, paren *token.Token, args []any) any {
+	function, ok := callee.(Callable)
+	if !ok {
+		panic(&errs.RuntimeError{Token: paren, Msg: "Can only call functions and classes."})
+	}
+
+	if arity := function.Arity(); arity != Variadic && len(args) != arity {
+		panic(&errs.RuntimeError{Token: paren, Msg: fmt.Sprintf("Expected %d arguments but got %d.", arity, len(args))})
+	}
+
+	defer i.enterCall(paren)()
+	return function.Call(i, paren, args)
+}
+
+// recoverRuntimeError recovers from a runtime error raised while interpreting
+// code and stores it in err.
+func (i *interpreter) recoverRuntimeError(err *error) {
+	if r := recover(); r != nil {
+		if e, ok := r.(*errs.RuntimeError); ok {
+			i.captureTrace(e)
+			*err = e
+		} else {
+			panic(fmt.Sprintf("Unhandled Panic (%T): %v", r, r))
+		}
+	}
+}
+
+func (i *interpreter) Resolve(stmts []ast.Stmt) errs.Diagnostics {
+	resolver := &resolver{interpreter: i, currentFunc: FTNone, currentClass: CTNone}
 	resolver.resolve(stmts)
+	return resolver.errors
 }
 
 func (i *interpreter) resolve(expr ast.Expr, depth int) {
@@ -55,5 +162,14 @@ func (i *interpreter) lookupVariable(name *token.Token, expr ast.Expr) any {
 		return i.environment.GetAt(distance, name.Lexeme)
 	}
 
-	return globals.Get(name)
+	return i.globals.Get(name)
+}
+
+// assignVariable assigns to the variable which the resolver found expr refers to.
+func (i *interpreter) assignVariable(name *token.Token, expr ast.Expr, value any) {
+	if distance, ok := i.locals[expr]; ok {
+		i.environment.AssignAt(distance, name, value)
+	} else {
+		i.globals.Assign(name, value)
+	}
 }
@@ -0,0 +1,149 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/DomBlack/lox/glox/pkg/ast"
+	"github.com/DomBlack/lox/glox/pkg/errs"
+	"github.com/DomBlack/lox/glox/pkg/token"
+)
+
+var (
+	// ErrStepLimitExceeded is wrapped by the runtime error raised when a program
+	// executes more statements than allowed by WithMaxSteps.
+	ErrStepLimitExceeded = errors.New("step limit exceeded")
+
+	// ErrStackOverflow is wrapped by the runtime error raised when calls are
+	// nested more deeply than allowed by WithMaxCallDepth.
+	ErrStackOverflow = errors.New("stack overflow")
+)
+
+// DefaultMaxCallDepth is how deeply function calls can be nested unless
+// changed by WithMaxCallDepth.
+const DefaultMaxCallDepth = 4096
+
+// WithMaxSteps limits the number of statements executed by each call to
+// Interpret or Call. Zero means no limit. Statements run by calls back into
+// the interpreter while it is running, such as from a native function, count
+// towards the budget of the outermost call.
+func WithMaxSteps(n int) Option {
+	return func(i *interpreter) {
+		i.maxSteps = n
+	}
+}
+
+// WithMaxCallDepth limits how deeply function calls can be nested, instead of
+// DefaultMaxCallDepth. There is always a limit, as otherwise deep recursion in
+// Lox would overflow the Go stack and crash the process, so zero or less
+// means DefaultMaxCallDepth.
+func WithMaxCallDepth(n int) Option {
+	return func(i *interpreter) {
+		if n <= 0 {
+			n = DefaultMaxCallDepth
+		}
+		i.maxDepth = n
+	}
+}
+
+// WithTimeout limits the wall-clock time taken by each call to Interpret or
+// Call. Zero means no limit.
+func WithTimeout(d time.Duration) Option {
+	return func(i *interpreter) {
+		i.timeout = d
+	}
+}
+
+// run runs fn under the given context and the interpreter's budgets,
+// recovering any runtime error raised by it.
+func (i *interpreter) run(ctx context.Context, fn func()) (err error) {
+	if i.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, i.timeout)
+		defer cancel()
+	}
+
+	previous, running := i.ctx, i.running
+	i.ctx = ctx
+	if !running {
+		i.running = true
+		i.steps = 0
+	}
+	defer func() {
+		i.ctx, i.running = previous, running
+	}()
+
+	defer i.recoverRuntimeError(&err)
+	fn()
+	return nil
+}
+
+// step is called before each statement is executed, and aborts execution if
+// the context is done or the step budget has been used up.
+func (i *interpreter) step(stmt ast.Stmt) {
+	select {
+	case <-i.ctx.Done():
+		err := i.ctx.Err()
+		msg := "Execution cancelled."
+		if errors.Is(err, context.DeadlineExceeded) {
+			msg = "Execution timed out."
+		}
+		panic(&errs.RuntimeError{Token: stmtToken(stmt), Msg: msg, Err: err})
+	default:
+	}
+
+	i.steps++
+	if i.maxSteps > 0 && i.steps > i.maxSteps {
+		panic(&errs.RuntimeError{Token: stmtToken(stmt), Msg: "Step limit exceeded.", Err: ErrStepLimitExceeded})
+	}
+}
+
+// stmtToken returns the token at the start of a statement, to report errors
+// raised before it is executed.
+func stmtToken(stmt ast.Stmt) *token.Token {
+	switch v := stmt.(type) {
+	case *ast.BadStmt:
+		return v.From
+	case *ast.BlockStmt:
+		return v.LeftBrace
+	case *ast.BreakStmt:
+		return v.Keyword
+	case *ast.ClassStmt:
+		return v.Keyword
+	case *ast.ContinueStmt:
+		return v.Keyword
+	case *ast.ExpressionStmt:
+		return v.Semicolon
+	case *ast.FunctionStmt:
+		return v.Name
+	case *ast.IfStmt:
+		return v.Keyword
+	case *ast.PrintStmt:
+		return v.Keyword
+	case *ast.ReturnStmt:
+		return v.Keyword
+	case *ast.VarStmt:
+		return v.Keyword
+	case *ast.WhileStmt:
+		return v.Keyword
+	default:
+		return nil
+	}
+}
+
+// enterCall is called before each function call, and raises a stack overflow
+// if the call would be nested too deeply. The returned function must be called
+// once the call is complete.
+func (i *interpreter) enterCall(paren *token.Token) func() {
+	if i.depth >= i.maxDepth {
+		panic(&errs.RuntimeError{Token: paren, Msg: "Stack overflow.", Err: ErrStackOverflow})
+	}
+
+	i.depth++
+	return i.exitCall
+}
+
+func (i *interpreter) exitCall() {
+	i.depth--
+}
@@ -0,0 +1,181 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/DomBlack/lox/glox/pkg/errs"
+	"github.com/DomBlack/lox/glox/pkg/token"
+)
+
+// List is a Lox list value, created with a literal such as [1, 2, 3].
+type List struct {
+	elements []any
+	printing bool // set while String is running, to detect a list which contains itself
+}
+
+var _ Object = (*List)(nil)
+var _ Indexable = (*List)(nil)
+
+// NewList creates a list of the given Lox values.
+func NewList(elements ...any) *List {
+	return &List{elements: elements}
+}
+
+// Elements returns the values in the list, which share storage with the list.
+func (l *List) Elements() []any {
+	return l.elements
+}
+
+// Get returns one of the list's methods, bound to the list.
+func (l *List) Get(name *token.Token) any {
+	switch name.Lexeme {
+	case "push":
+		return NewNative("push", 1, func(args Args) (any, error) {
+			l.elements = append(l.elements, args[0])
+			return nil, nil
+		})
+	case "pop":
+		return NewNative("pop", 0, func(_ Args) (any, error) {
+			if len(l.elements) == 0 {
+				return nil, errors.New("Cannot pop from an empty list.")
+			}
+
+			value := l.elements[len(l.elements)-1]
+			l.elements = l.elements[:len(l.elements)-1]
+			return value, nil
+		})
+	case "insert":
+		return NewNative("insert", 2, func(args Args) (any, error) {
+			// Inserting at the length of the list appends to it.
+			i, err := l.argIndex(args, 0, len(l.elements)+1)
+			if err != nil {
+				return nil, err
+			}
+
+			l.elements = append(l.elements, nil)
+			copy(l.elements[i+1:], l.elements[i:])
+			l.elements[i] = args[1]
+			return nil, nil
+		})
+	case "remove":
+		return NewNative("remove", 1, func(args Args) (any, error) {
+			i, err := l.argIndex(args, 0, len(l.elements))
+			if err != nil {
+				return nil, err
+			}
+
+			value := l.elements[i]
+			l.elements = append(l.elements[:i], l.elements[i+1:]...)
+			return value, nil
+		})
+	case "contains":
+		return NewNative("contains", 1, func(args Args) (any, error) {
+			for _, element := range l.elements {
+				if isEqual(element, args[0]) {
+					return true, nil
+				}
+			}
+			return false, nil
+		})
+	}
+
+	panic(&errs.RuntimeError{Token: name, Msg: fmt.Sprintf("Undefined property '%s'.", name.Lexeme)})
+}
+
+func (l *List) Set(name *token.Token, _ any) {
+	panic(&errs.RuntimeError{Token: name, Msg: "Cannot add properties to a list."})
+}
+
+// Index returns the element at index, which counts back from the end of the
+// list if it is negative.
+func (l *List) Index(bracket *token.Token, index any) any {
+	i, err := l.index(index, len(l.elements))
+	if err != nil {
+		panic(&errs.RuntimeError{Token: bracket, Msg: err.Error()})
+	}
+
+	return l.elements[i]
+}
+
+// SetIndex replaces the element at index, which counts back from the end of
+// the list if it is negative.
+func (l *List) SetIndex(bracket *token.Token, index any, value any) {
+	i, err := l.index(index, len(l.elements))
+	if err != nil {
+		panic(&errs.RuntimeError{Token: bracket, Msg: err.Error()})
+	}
+
+	l.elements[i] = value
+}
+
+// Slice returns a new list of the elements from low up to but not including
+// high. Either bound may be nil to slice from the start or to the end, or
+// negative to count back from the end. Bounds outside the list are clamped.
+func (l *List) Slice(bracket *token.Token, low, high any) *List {
+	bound := func(value any, def int) int {
+		if value == nil {
+			return def
+		}
+
+		n, ok := value.(float64)
+		if !ok || n != math.Trunc(n) {
+			panic(&errs.RuntimeError{Token: bracket, Msg: "Slice bounds must be integers."})
+		}
+		if n < 0 {
+			n += float64(len(l.elements))
+		}
+		return int(math.Max(0, math.Min(n, float64(len(l.elements)))))
+	}
+
+	start, end := bound(low, 0), bound(high, len(l.elements))
+	if end < start {
+		end = start
+	}
+
+	elements := make([]any, end-start)
+	copy(elements, l.elements[start:end])
+	return NewList(elements...)
+}
+
+// index converts a Lox index to a Go one, which must be less than length.
+func (l *List) index(index any, length int) (int, error) {
+	n, ok := index.(float64)
+	if !ok || n != math.Trunc(n) {
+		return 0, errors.New("List index must be an integer.")
+	}
+
+	if n < 0 {
+		n += float64(len(l.elements))
+	}
+	if n < 0 || n >= float64(length) {
+		return 0, errors.New("List index out of range.")
+	}
+
+	return int(n), nil
+}
+
+// argIndex converts argument i of a list method to a Go index.
+func (l *List) argIndex(args Args, i int, length int) (int, error) {
+	if _, err := args.NumberAt(i); err != nil {
+		return 0, err
+	}
+
+	return l.index(args[i], length)
+}
+
+func (l *List) String() string {
+	if l.printing {
+		return "[...]"
+	}
+	l.printing = true
+	defer func() { l.printing = false }()
+
+	elements := make([]string, len(l.elements))
+	for i, element := range l.elements {
+		elements[i] = stringify(element)
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
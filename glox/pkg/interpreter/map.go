@@ -0,0 +1,178 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/DomBlack/lox/glox/pkg/errs"
+	"github.com/DomBlack/lox/glox/pkg/token"
+)
+
+// Map is a Lox map value, created with a literal such as {"a": 1}. Keys are
+// compared as with '==', and are kept in the order they were first added.
+type Map struct {
+	keys     []any
+	values   []any
+	index    map[any]int // position of each key, indexed by its hashKey
+	printing bool        // set while String is running, to detect a map which contains itself
+}
+
+var _ Object = (*Map)(nil)
+var _ Indexable = (*Map)(nil)
+
+// ErrInvalidMapKey is returned when storing a value under a key which can't
+// be compared, such as a Go slice returned by a method of a GoInstance. It is
+// wrapped by the runtime error raised when Lox code does so.
+var ErrInvalidMapKey = errors.New("invalid map key")
+
+// NewMap creates an empty map.
+func NewMap() *Map {
+	return &Map{index: make(map[any]int)}
+}
+
+// Len returns the number of entries in the map.
+func (m *Map) Len() int {
+	return len(m.keys)
+}
+
+// Lookup returns the value stored for key, and whether there was one.
+func (m *Map) Lookup(key any) (any, bool) {
+	hash, ok := hashKey(key)
+	if !ok {
+		return nil, false
+	}
+
+	i, ok := m.index[hash]
+	if !ok {
+		return nil, false
+	}
+
+	return m.values[i], true
+}
+
+// Store sets the value for key. A new key is added after all the others,
+// while an existing key keeps its place. It returns ErrInvalidMapKey if the
+// key can't be compared.
+func (m *Map) Store(key any, value any) error {
+	hash, ok := hashKey(key)
+	if !ok {
+		return ErrInvalidMapKey
+	}
+
+	if i, ok := m.index[hash]; ok {
+		m.values[i] = value
+		return nil
+	}
+
+	m.index[hash] = len(m.keys)
+	m.keys = append(m.keys, key)
+	m.values = append(m.values, value)
+	return nil
+}
+
+// Delete removes key from the map, and reports whether it was present.
+func (m *Map) Delete(key any) bool {
+	hash, ok := hashKey(key)
+	if !ok {
+		return false
+	}
+
+	i, ok := m.index[hash]
+	if !ok {
+		return false
+	}
+
+	delete(m.index, hash)
+	m.keys = append(m.keys[:i], m.keys[i+1:]...)
+	m.values = append(m.values[:i], m.values[i+1:]...)
+	for _, key := range m.keys[i:] {
+		moved, _ := hashKey(key)
+		m.index[moved]--
+	}
+	return true
+}
+
+// hashKey returns a Go map key for a Lox value, such that two values have the
+// same hashKey exactly when isEqual reports them as equal. It returns false if
+// the value can't be used as a Go map key, which is possible for values
+// returned from Go such as slices.
+func hashKey(value any) (any, bool) {
+	// Go instances are equal if they wrap the same struct, even when they
+	// are different *GoInstance values.
+	if instance, ok := value.(*GoInstance); ok {
+		return goInstanceKey{pointer: instance.value.Pointer(), typ: instance.value.Type()}, true
+	}
+
+	if value != nil && !reflect.TypeOf(value).Comparable() {
+		return nil, false
+	}
+
+	return value, true
+}
+
+type goInstanceKey struct {
+	pointer uintptr
+	typ     reflect.Type
+}
+
+// Get returns one of the map's methods, bound to the map.
+func (m *Map) Get(name *token.Token) any {
+	switch name.Lexeme {
+	case "keys":
+		return NewNative("keys", 0, func(_ Args) (any, error) {
+			return NewList(append([]any(nil), m.keys...)...), nil
+		})
+	case "values":
+		return NewNative("values", 0, func(_ Args) (any, error) {
+			return NewList(append([]any(nil), m.values...)...), nil
+		})
+	case "has":
+		return NewNative("has", 1, func(args Args) (any, error) {
+			_, ok := m.Lookup(args[0])
+			return ok, nil
+		})
+	case "delete":
+		return NewNative("delete", 1, func(args Args) (any, error) {
+			return m.Delete(args[0]), nil
+		})
+	}
+
+	panic(&errs.RuntimeError{Token: name, Msg: fmt.Sprintf("Undefined property '%s'.", name.Lexeme)})
+}
+
+func (m *Map) Set(name *token.Token, _ any) {
+	panic(&errs.RuntimeError{Token: name, Msg: "Cannot add properties to a map."})
+}
+
+// Index returns the value stored for key, which must be in the map.
+func (m *Map) Index(bracket *token.Token, key any) any {
+	value, ok := m.Lookup(key)
+	if !ok {
+		panic(&errs.RuntimeError{Token: bracket, Msg: fmt.Sprintf("Undefined key %s.", stringify(key))})
+	}
+
+	return value
+}
+
+// SetIndex sets the value for key, adding it to the map if needed.
+func (m *Map) SetIndex(bracket *token.Token, key any, value any) {
+	if err := m.Store(key, value); err != nil {
+		panic(&errs.RuntimeError{Token: bracket, Msg: "Invalid map key.", Err: err})
+	}
+}
+
+func (m *Map) String() string {
+	if m.printing {
+		return "{...}"
+	}
+	m.printing = true
+	defer func() { m.printing = false }()
+
+	entries := make([]string, len(m.keys))
+	for i, key := range m.keys {
+		entries[i] = stringify(key) + ": " + stringify(m.values[i])
+	}
+	return "{" + strings.Join(entries, ", ") + "}"
+}
@@ -0,0 +1,87 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/DomBlack/lox/glox/pkg/errs"
+	"github.com/DomBlack/lox/glox/pkg/token"
+)
+
+// Variadic is the arity of a native function which accepts any number of arguments.
+const Variadic = -1
+
+// Native is a function implemented in Go which can be called from Lox.
+type Native struct {
+	name  string
+	arity int
+	fn    func(args Args) (any, error)
+}
+
+var _ Callable = (*Native)(nil)
+
+// NewNative creates a native function which takes arity arguments, or any
+// number if arity is Variadic. The function must return a Lox value (nil, bool,
+// float64, string or a value obtained from the interpreter); if it returns an
+// error, a runtime error is raised at the call site.
+func NewNative(name string, arity int, fn func(args Args) (any, error)) *Native {
+	return &Native{name: name, arity: arity, fn: fn}
+}
+
+func (n *Native) Arity() int {
+	return n.arity
+}
+
+func (n *Native) Call(_ *interpreter, paren *token.Token, arguments []any) any {
+	value, err := n.fn(arguments)
+	if err != nil {
+		panic(&errs.RuntimeError{Token: paren, Msg: err.Error()})
+	}
+
+	return value
+}
+
+func (n *Native) String() string {
+	return fmt.Sprintf("<native fn %s>", n.name)
+}
+
+// Args are the arguments passed to a native function.
+type Args []any
+
+// NumberAt returns argument i, or an error if it is not a number.
+func (a Args) NumberAt(i int) (float64, error) {
+	if value, ok := a.at(i).(float64); ok {
+		return value, nil
+	}
+
+	return 0, a.typeError(i, "a number")
+}
+
+// StringAt returns argument i, or an error if it is not a string.
+func (a Args) StringAt(i int) (string, error) {
+	if value, ok := a.at(i).(string); ok {
+		return value, nil
+	}
+
+	return "", a.typeError(i, "a string")
+}
+
+// BoolAt returns argument i, or an error if it is not a boolean.
+func (a Args) BoolAt(i int) (bool, error) {
+	if value, ok := a.at(i).(bool); ok {
+		return value, nil
+	}
+
+	return false, a.typeError(i, "a boolean")
+}
+
+func (a Args) at(i int) any {
+	if i < 0 || i >= len(a) {
+		return nil
+	}
+
+	return a[i]
+}
+
+func (a Args) typeError(i int, expected string) error {
+	return fmt.Errorf("Argument %d must be %s.", i+1, expected)
+}
@@ -0,0 +1,22 @@
+package interpreter
+
+import (
+	"github.com/DomBlack/lox/glox/pkg/token"
+)
+
+// Object is a value which has properties that can be read and written with
+// the '.' operator.
+type Object interface {
+	Get(name *token.Token) any
+	Set(name *token.Token, value any)
+}
+
+var _ Object = (*Instance)(nil)
+var _ Object = (*GoInstance)(nil)
+
+// Indexable is a value whose elements can be read and written with the '[]'
+// operator. Errors are reported at the token of the opening bracket.
+type Indexable interface {
+	Index(bracket *token.Token, index any) any
+	SetIndex(bracket *token.Token, index any, value any)
+}
@@ -11,6 +11,8 @@ type resolver struct {
 	scopes       []map[string]bool
 	currentFunc  FunctionType
 	currentClass ClassType
+	loops        []string // labels of the enclosing loops in the current function
+	errors       errs.Diagnostics
 }
 
 var _ ast.StmtVisitor[any] = (*resolver)(nil)
@@ -45,7 +47,7 @@ func (r *resolver) declare(name *token.Token) {
 
 	scope := r.scopes[len(r.scopes)-1]
 	if _, ok := scope[name.Lexeme]; ok {
-		errs.ErrorAtToken(name, "Already a variable with this name in this scope.")
+		r.errors.ErrorAtToken(name, errs.DuplicateVariable, "Already a variable with this name in this scope.")
 	}
 
 	scope[name.Lexeme] = false
@@ -68,19 +70,23 @@ func (r *resolver) resolveLocal(expr ast.Expr, name *token.Token) {
 	}
 }
 
-func (r *resolver) resolveFunction(fn *ast.FunctionStmt, funcType FunctionType) {
-	enclosingFunc := r.currentFunc
-	r.currentFunc = funcType
+func (r *resolver) resolveFunction(params []*token.Token, body []ast.Stmt, funcType FunctionType) {
+	enclosingFunc, enclosingLoops := r.currentFunc, r.loops
+	r.currentFunc, r.loops = funcType, nil
 
 	r.beginScope()
-	for _, param := range fn.Params {
+	for _, param := range params {
 		r.declare(param)
 		r.define(param)
 	}
-	r.resolve(fn.Body)
+	r.resolve(body)
 	r.endScope()
 
-	r.currentFunc = enclosingFunc
+	r.currentFunc, r.loops = enclosingFunc, enclosingLoops
+}
+
+func (r *resolver) VisitBadStmt(_ *ast.BadStmt) any {
+	return nil
 }
 
 func (r *resolver) VisitBlockStmt(v *ast.BlockStmt) any {
@@ -103,7 +109,7 @@ func (r *resolver) VisitClassStmt(v *ast.ClassStmt) any {
 	r.define(v.Name)
 
 	if v.Superclass != nil && v.Name.Lexeme == v.Superclass.Name.Lexeme {
-		errs.ErrorAtToken(v.Superclass.Name, "A class cannot inherit from itself.")
+		r.errors.ErrorAtToken(v.Superclass.Name, errs.InheritFromSelf, "A class cannot inherit from itself.")
 	}
 
 	if v.Superclass != nil {
@@ -124,7 +130,7 @@ func (r *resolver) VisitClassStmt(v *ast.ClassStmt) any {
 		if method.Name.Lexeme == "init" {
 			declaration = FTInitializer
 		}
-		r.resolveFunction(method, declaration)
+		r.resolveFunction(method.Params, method.Body, declaration)
 	}
 
 	r.endScope()
@@ -140,7 +146,7 @@ func (r *resolver) VisitClassStmt(v *ast.ClassStmt) any {
 func (r *resolver) VisitFunctionStmt(v *ast.FunctionStmt) any {
 	r.declare(v.Name)
 	r.define(v.Name)
-	r.resolveFunction(v, FTFunction)
+	r.resolveFunction(v.Params, v.Body, FTFunction)
 	return nil
 }
 
@@ -160,12 +166,12 @@ func (r *resolver) VisitPrintStmt(v *ast.PrintStmt) any {
 
 func (r *resolver) VisitReturnStmt(v *ast.ReturnStmt) any {
 	if r.currentFunc == FTNone {
-		errs.ErrorAtToken(v.Keyword, "Cannot return from top-level code.")
+		r.errors.ErrorAtToken(v.Keyword, errs.TopLevelReturn, "Cannot return from top-level code.")
 	}
 
 	if v.Value != nil {
 		if r.currentFunc == FTInitializer {
-			errs.ErrorAtToken(v.Keyword, "Cannot return a value from an initializer.")
+			r.errors.ErrorAtToken(v.Keyword, errs.ReturnFromInitializer, "Cannot return a value from an initializer.")
 		}
 		r.resolveExpr(v.Value)
 	}
@@ -183,16 +189,59 @@ func (r *resolver) VisitVarStmt(v *ast.VarStmt) any {
 
 func (r *resolver) VisitWhileStmt(v *ast.WhileStmt) any {
 	r.resolveExpr(v.Condition)
+
+	r.loops = append(r.loops, label(v.Label))
 	r.resolveStmt(v.Body)
+	r.loops = r.loops[:len(r.loops)-1]
+
+	if v.Increment != nil {
+		r.resolveExpr(v.Increment)
+	}
 	return nil
 }
 
+func (r *resolver) VisitBreakStmt(v *ast.BreakStmt) any {
+	if len(r.loops) == 0 {
+		r.errors.ErrorAtToken(v.Keyword, errs.BreakOutsideLoop, "Cannot use 'break' outside of a loop.")
+	} else {
+		r.resolveLabel(v.Label)
+	}
+	return nil
+}
+
+func (r *resolver) VisitContinueStmt(v *ast.ContinueStmt) any {
+	if len(r.loops) == 0 {
+		r.errors.ErrorAtToken(v.Keyword, errs.ContinueOutsideLoop, "Cannot use 'continue' outside of a loop.")
+	} else {
+		r.resolveLabel(v.Label)
+	}
+	return nil
+}
+
+// resolveLabel checks that a break or continue label names an enclosing loop.
+func (r *resolver) resolveLabel(name *token.Token) {
+	if name == nil {
+		return
+	}
+
+	for _, loop := range r.loops {
+		if loop == name.Lexeme {
+			return
+		}
+	}
+	r.errors.ErrorAtToken(name, errs.UndefinedLabel, "Undefined label '"+name.Lexeme+"'.")
+}
+
 func (r *resolver) VisitAssignExpr(v *ast.AssignExpr) any {
 	r.resolveExpr(v.Value)
 	r.resolveLocal(v, v.Name)
 	return nil
 }
 
+func (r *resolver) VisitBadExpr(_ *ast.BadExpr) any {
+	return nil
+}
+
 func (r *resolver) VisitBinaryExpr(v *ast.BinaryExpr) any {
 	r.resolveExpr(v.Left)
 	r.resolveExpr(v.Right)
@@ -207,11 +256,67 @@ func (r *resolver) VisitCallExpr(v *ast.CallExpr) any {
 	return nil
 }
 
+func (r *resolver) VisitFunctionExpr(v *ast.FunctionExpr) any {
+	r.resolveFunction(v.Params, v.Body, FTFunction)
+	return nil
+}
+
+func (r *resolver) VisitConditionalExpr(v *ast.ConditionalExpr) any {
+	r.resolveExpr(v.Condition)
+	r.resolveExpr(v.ThenBranch)
+	r.resolveExpr(v.ElseBranch)
+	return nil
+}
+
+func (r *resolver) VisitOptionalChainExpr(v *ast.OptionalChainExpr) any {
+	r.resolveExpr(v.Expression)
+	return nil
+}
+
 func (r *resolver) VisitGetExpr(v *ast.GetExpr) any {
 	r.resolveExpr(v.Object)
 	return nil
 }
 
+func (r *resolver) VisitIndexExpr(v *ast.IndexExpr) any {
+	r.resolveExpr(v.Object)
+	r.resolveExpr(v.Index)
+	return nil
+}
+
+func (r *resolver) VisitIndexSetExpr(v *ast.IndexSetExpr) any {
+	r.resolveExpr(v.Value)
+	r.resolveExpr(v.Object)
+	r.resolveExpr(v.Index)
+	return nil
+}
+
+func (r *resolver) VisitListExpr(v *ast.ListExpr) any {
+	for _, element := range v.Elements {
+		r.resolveExpr(element)
+	}
+	return nil
+}
+
+func (r *resolver) VisitMapExpr(v *ast.MapExpr) any {
+	for i, key := range v.Keys {
+		r.resolveExpr(key)
+		r.resolveExpr(v.Values[i])
+	}
+	return nil
+}
+
+func (r *resolver) VisitSliceExpr(v *ast.SliceExpr) any {
+	r.resolveExpr(v.Object)
+	if v.Low != nil {
+		r.resolveExpr(v.Low)
+	}
+	if v.High != nil {
+		r.resolveExpr(v.High)
+	}
+	return nil
+}
+
 func (r *resolver) VisitGroupingExpr(v *ast.GroupingExpr) any {
 	r.resolveExpr(v.Expression)
 	return nil
@@ -231,9 +336,9 @@ func (r *resolver) VisitSetExpr(v *ast.SetExpr) any {
 
 func (r *resolver) VisitSuperExpr(v *ast.SuperExpr) any {
 	if r.currentClass == CTNone {
-		errs.ErrorAtToken(v.Keyword, "Cannot use 'super' outside of a class.")
+		r.errors.ErrorAtToken(v.Keyword, errs.SuperOutsideClass, "Cannot use 'super' outside of a class.")
 	} else if r.currentClass != CTSubclass {
-		errs.ErrorAtToken(v.Keyword, "Cannot use 'super' in a class with no superclass.")
+		r.errors.ErrorAtToken(v.Keyword, errs.SuperWithoutSuperclass, "Cannot use 'super' in a class with no superclass.")
 	}
 
 	r.resolveLocal(v, v.Keyword)
@@ -242,7 +347,7 @@ func (r *resolver) VisitSuperExpr(v *ast.SuperExpr) any {
 
 func (r *resolver) VisitThisExpr(v *ast.ThisExpr) any {
 	if r.currentClass == CTNone {
-		errs.ErrorAtToken(v.Keyword, "Cannot use 'this' outside of a class.")
+		r.errors.ErrorAtToken(v.Keyword, errs.ThisOutsideClass, "Cannot use 'this' outside of a class.")
 		return nil
 	}
 
@@ -250,10 +355,22 @@ func (r *resolver) VisitThisExpr(v *ast.ThisExpr) any {
 	return nil
 }
 
+func (r *resolver) VisitInterpolationExpr(v *ast.InterpolationExpr) any {
+	for _, part := range v.Parts {
+		r.resolveExpr(part)
+	}
+	return nil
+}
+
 func (r *resolver) VisitLiteralExpr(v *ast.LiteralExpr) any {
 	return nil
 }
 
+func (r *resolver) VisitUpdateExpr(v *ast.UpdateExpr) any {
+	r.resolveExpr(v.Target)
+	return nil
+}
+
 func (r *resolver) VisitUnaryExpr(v *ast.UnaryExpr) any {
 	r.resolveExpr(v.Right)
 	return nil
@@ -262,7 +379,7 @@ func (r *resolver) VisitUnaryExpr(v *ast.UnaryExpr) any {
 func (r *resolver) VisitVariableExpr(v *ast.VariableExpr) any {
 	if len(r.scopes) != 0 {
 		if defined, found := r.scopes[len(r.scopes)-1][v.Name.Lexeme]; !defined && found {
-			errs.ErrorAtToken(v.Name, "Cannot read local variable in its own initializer.")
+			r.errors.ErrorAtToken(v.Name, errs.ReadInOwnInitializer, "Cannot read local variable in its own initializer.")
 		}
 	}
 
@@ -3,3 +3,15 @@ package interpreter
 type Return struct {
 	Value any
 }
+
+// Break unwinds to the end of the loop with the given label, or the innermost
+// loop if there is no label.
+type Break struct {
+	Label string
+}
+
+// Continue unwinds to the next iteration of the loop with the given label, or
+// the innermost loop if there is no label.
+type Continue struct {
+	Label string
+}
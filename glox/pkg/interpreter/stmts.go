@@ -1,13 +1,17 @@
 package interpreter
 
 import (
+	"fmt"
+
 	"github.com/DomBlack/lox/glox/pkg/ast"
 	"github.com/DomBlack/lox/glox/pkg/errs"
+	"github.com/DomBlack/lox/glox/pkg/token"
 )
 
 var _ ast.StmtVisitor[any] = (*interpreter)(nil)
 
 func (i *interpreter) execute(stmt ast.Stmt) {
+	i.step(stmt)
 	ast.AcceptStmt[any](stmt, i)
 }
 
@@ -23,6 +27,26 @@ func (i *interpreter) executeBlock(stmts []ast.Stmt, environment *Environment) {
 	}
 }
 
+func (i *interpreter) VisitBadStmt(v *ast.BadStmt) any {
+	panic(&errs.RuntimeError{Token: v.From, Msg: "Cannot run code with syntax errors."})
+}
+
+func (i *interpreter) VisitBreakStmt(v *ast.BreakStmt) any {
+	panic(&Break{Label: label(v.Label)})
+}
+
+func (i *interpreter) VisitContinueStmt(v *ast.ContinueStmt) any {
+	panic(&Continue{Label: label(v.Label)})
+}
+
+// label returns the name of an optional label.
+func label(t *token.Token) string {
+	if t == nil {
+		return ""
+	}
+	return t.Lexeme
+}
+
 func (i *interpreter) VisitBlockStmt(stmt *ast.BlockStmt) any {
 	i.executeBlock(stmt.Statements, i.environment.Scope())
 	return nil
@@ -47,7 +71,7 @@ func (i *interpreter) VisitClassStmt(v *ast.ClassStmt) any {
 	if v.Superclass != nil {
 		super, ok := i.evaluate(v.Superclass).(*Class)
 		if !ok {
-			panic(&errs.RuntimeError{v.Superclass.Name, "Superclass must be a class."})
+			panic(&errs.RuntimeError{Token: v.Superclass.Name, Msg: "Superclass must be a class."})
 		}
 		superclass = super
 	}
@@ -59,14 +83,19 @@ func (i *interpreter) VisitClassStmt(v *ast.ClassStmt) any {
 		i.environment.Define("super", superclass)
 	}
 
-	methods := make(map[string]*Function)
+	class := &Class{Name: v.Name.Lexeme, Super: superclass, Methods: make(map[string]*Function)}
 	for _, method := range v.Methods {
-		function := &Function{method, i.environment, method.Name.Lexeme == "init"}
-		methods[method.Name.Lexeme] = function
+		function := &Function{
+			name:          method.Name.Lexeme,
+			params:        method.Params,
+			body:          method.Body,
+			closure:       i.environment,
+			isInitializer: method.Name.Lexeme == "init",
+			class:         class,
+		}
+		class.Methods[method.Name.Lexeme] = function
 	}
 
-	class := &Class{v.Name.Lexeme, superclass, methods}
-
 	if superclass != nil {
 		i.environment = i.environment.Enclosing
 	}
@@ -76,14 +105,14 @@ func (i *interpreter) VisitClassStmt(v *ast.ClassStmt) any {
 }
 
 func (i *interpreter) VisitFunctionStmt(v *ast.FunctionStmt) any {
-	function := &Function{v, i.environment, false}
+	function := &Function{name: v.Name.Lexeme, params: v.Params, body: v.Body, closure: i.environment}
 	i.environment.Define(v.Name.Lexeme, function)
 	return nil
 }
 
 func (i *interpreter) VisitPrintStmt(v *ast.PrintStmt) any {
 	value := i.evaluate(v.Expression)
-	println(stringify(value))
+	_, _ = fmt.Fprintln(i.stdout, stringify(value))
 	return nil
 }
 
@@ -98,11 +127,47 @@ func (i *interpreter) VisitReturnStmt(v *ast.ReturnStmt) any {
 
 func (i *interpreter) VisitWhileStmt(v *ast.WhileStmt) any {
 	for isTruthy(i.evaluate(v.Condition)) {
-		i.execute(v.Body)
+		if !i.executeLoopBody(v) {
+			break
+		}
+
+		if v.Increment != nil {
+			i.evaluate(v.Increment)
+		}
 	}
 	return nil
 }
 
+// executeLoopBody executes one iteration of a loop, returning false if it was
+// ended by a break statement.
+func (i *interpreter) executeLoopBody(v *ast.WhileStmt) (next bool) {
+	// matches reports whether a break or continue with the label targets v.
+	matches := func(label string) bool {
+		return label == "" || (v.Label != nil && v.Label.Lexeme == label)
+	}
+
+	defer func() {
+		switch r := recover().(type) {
+		case nil:
+		case *Break:
+			if !matches(r.Label) {
+				panic(r)
+			}
+			next = false
+		case *Continue:
+			if !matches(r.Label) {
+				panic(r)
+			}
+			next = true
+		default:
+			panic(r)
+		}
+	}()
+
+	i.execute(v.Body)
+	return true
+}
+
 func (i *interpreter) VisitVarStmt(v *ast.VarStmt) any {
 	var value any
 	if v.Initializer != nil {
@@ -0,0 +1,58 @@
+package interpreter
+
+import (
+	"github.com/DomBlack/lox/glox/pkg/errs"
+	"github.com/DomBlack/lox/glox/pkg/token"
+)
+
+// frame is a call to a Lox function which has not yet returned.
+type frame struct {
+	function *Function
+	callSite *token.Token // the closing parenthesis of the call, or nil if called from Go
+}
+
+func (i *interpreter) pushFrame(function *Function, callSite *token.Token) {
+	i.frames = append(i.frames, frame{function, callSite})
+}
+
+func (i *interpreter) popFrame() {
+	i.frames = i.frames[:len(i.frames)-1]
+}
+
+// captureTrace records the current call stack on the error, innermost frame
+// first, unless a trace has already been captured.
+func (i *interpreter) captureTrace(e *errs.RuntimeError) {
+	if e.Trace != nil {
+		return
+	}
+
+	e.Trace = []errs.Frame{}
+	line := 0
+	if e.Token != nil {
+		line = e.Token.Line
+	}
+
+	for n := len(i.frames) - 1; n >= 0; n-- {
+		e.Trace = append(e.Trace, i.frames[n].function.frame(line))
+
+		if i.frames[n].callSite == nil {
+			// Called from Go, so there are no more Lox frames to report.
+			return
+		}
+		line = i.frames[n].callSite.Line
+	}
+
+	if line != 0 {
+		e.Trace = append(e.Trace, errs.Frame{Line: line})
+	}
+}
+
+// frame describes the function for a stack trace.
+func (f *Function) frame(line int) errs.Frame {
+	frame := errs.Frame{Function: f.Name(), Line: line}
+	if f.class != nil {
+		frame.Class = f.class.Name
+	}
+
+	return frame
+}
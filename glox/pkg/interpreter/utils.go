@@ -27,6 +27,13 @@ func isEqual(a any, b any) bool {
 		return false
 	}
 
+	// Go instances are equal if they wrap the same struct.
+	if a, ok := a.(*GoInstance); ok {
+		if b, ok := b.(*GoInstance); ok {
+			return a.value.Pointer() == b.value.Pointer() && a.value.Type() == b.value.Type()
+		}
+	}
+
 	return a == b
 }
 
@@ -0,0 +1,201 @@
+// Package lox provides an embeddable runtime for Lox programs.
+package lox
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/DomBlack/lox/glox/pkg/ast"
+	"github.com/DomBlack/lox/glox/pkg/errs"
+	"github.com/DomBlack/lox/glox/pkg/interpreter"
+	"github.com/DomBlack/lox/glox/pkg/parser"
+	"github.com/DomBlack/lox/glox/pkg/scanner"
+)
+
+// Runtime scans, parses and interprets Lox source code. Global state is kept
+// between calls to Run, so a Runtime can be used to drive a REPL.
+type Runtime struct {
+	interpreter interpreter.Interpreter
+	stdout      io.Writer
+	stderr      io.Writer
+	format      errs.Format
+	color       bool
+	options     []interpreter.Option
+}
+
+// Option configures a Runtime created by New.
+type Option func(r *Runtime)
+
+// WithStdout sets the writer that program output is written to, by default os.Stdout.
+func WithStdout(w io.Writer) Option {
+	return func(r *Runtime) {
+		r.stdout = w
+	}
+}
+
+// WithStderr sets the writer that diagnostics are written to, by default os.Stderr.
+func WithStderr(w io.Writer) Option {
+	return func(r *Runtime) {
+		r.stderr = w
+	}
+}
+
+// WithErrorFormat sets how errors are written to the diagnostics writer, by
+// default errs.FormatPlain.
+func WithErrorFormat(format errs.Format) Option {
+	return func(r *Runtime) {
+		r.format = format
+	}
+}
+
+// WithColor sets whether errors written in errs.FormatText use ANSI colors.
+func WithColor(color bool) Option {
+	return func(r *Runtime) {
+		r.color = color
+	}
+}
+
+// WithInterpreterOptions passes options, such as execution budgets, to the
+// underlying interpreter.
+func WithInterpreterOptions(opts ...interpreter.Option) Option {
+	return func(r *Runtime) {
+		r.options = append(r.options, opts...)
+	}
+}
+
+func New(opts ...Option) *Runtime {
+	r := &Runtime{
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	r.interpreter = interpreter.New(append(r.options, interpreter.WithStdout(r.stdout))...)
+	return r
+}
+
+// Run executes the given source code. Any diagnostics or errors are written to
+// the diagnostics writer; compile errors are returned as errs.Diagnostics and
+// runtime errors as an *errs.RuntimeError.
+func (r *Runtime) Run(source string) error {
+	return r.RunContext(context.Background(), source)
+}
+
+// RunContext is like Run, but stops with a runtime error wrapping the context's
+// error if ctx is done before the program finishes.
+func (r *Runtime) RunContext(ctx context.Context, source string) error {
+	return r.RunSource(ctx, "", source)
+}
+
+// RunSource is like RunContext, but records the name of the file the source
+// was read from in the positions of any diagnostics.
+func (r *Runtime) RunSource(ctx context.Context, filename string, source string) error {
+	stmts, diagnostics := parse(scanner.NewFile(filename, source))
+	return r.run(ctx, source, stmts, diagnostics)
+}
+
+// RunReader is like RunSource, but scans and parses the program as it is read
+// from src. The program only starts once all of it has been parsed.
+func (r *Runtime) RunReader(ctx context.Context, filename string, src io.Reader) error {
+	src, kept := r.keepSource(src)
+	stmts, diagnostics := parse(scanner.NewReader(filename, src))
+
+	var source string
+	if kept != nil {
+		source = kept.String()
+	}
+	return r.run(ctx, source, stmts, diagnostics)
+}
+
+// keepSource returns a reader for src which also records the source in the
+// returned builder, if it is needed for diagnostics to show the offending
+// lines. Otherwise src is returned as it is with a nil builder, so that the
+// program isn't held in memory.
+func (r *Runtime) keepSource(src io.Reader) (io.Reader, *strings.Builder) {
+	if r.format != errs.FormatText {
+		return src, nil
+	}
+
+	source := &strings.Builder{}
+	return io.TeeReader(src, source), source
+}
+
+// parse parses the program as it is scanned by s.
+func parse(s *scanner.Scanner) ([]ast.Stmt, errs.Diagnostics) {
+	stmts, diagnostics := parser.NewStream(s).Parse()
+	return stmts, append(s.Errors(), diagnostics...)
+}
+
+// run resolves and interprets a parsed program, unless it has syntax errors.
+func (r *Runtime) run(ctx context.Context, source string, stmts []ast.Stmt, diagnostics errs.Diagnostics) error {
+	if err := r.check(source, diagnostics); err != nil {
+		return err
+	}
+
+	if err := r.check(source, r.interpreter.Resolve(stmts)); err != nil {
+		return err
+	}
+
+	if err := r.interpreter.Interpret(ctx, stmts); err != nil {
+		return r.report(source, err)
+	}
+
+	return nil
+}
+
+// check reports any diagnostics, returning them as an error if there were any errors.
+func (r *Runtime) check(source string, diagnostics errs.Diagnostics) error {
+	if len(diagnostics) > 0 {
+		_ = r.report(source, diagnostics)
+	}
+
+	return diagnostics.Err()
+}
+
+func (r *Runtime) report(source string, err error) error {
+	renderer := &errs.Renderer{Format: r.format, Color: r.color, Source: source}
+	_ = renderer.Render(r.stderr, err)
+	return err
+}
+
+// Define sets a global variable, such as an *interpreter.Native function, which
+// is visible to all code subsequently run.
+func (r *Runtime) Define(name string, value any) {
+	r.interpreter.Define(name, value)
+}
+
+// Get returns the value of a global variable, and whether it is defined.
+func (r *Runtime) Get(name string) (any, bool) {
+	return r.interpreter.Get(name)
+}
+
+// Call calls a Lox function, class or native function, such as one returned by
+// Get, with the given arguments. Go values such as ints are converted to Lox
+// values. Runtime errors are returned rather than written to the diagnostics
+// writer.
+func (r *Runtime) Call(callee any, args ...any) (any, error) {
+	return r.CallContext(context.Background(), callee, args...)
+}
+
+// CallContext is like Call, but stops with a runtime error wrapping the
+// context's error if ctx is done before the call returns.
+func (r *Runtime) CallContext(ctx context.Context, callee any, args ...any) (any, error) {
+	return r.interpreter.Call(ctx, callee, args...)
+}
+
+// Invoke calls the named method of an instance with the given arguments.
+// Runtime errors are returned rather than written to the diagnostics writer.
+func (r *Runtime) Invoke(instance *interpreter.Instance, method string, args ...any) (any, error) {
+	return r.InvokeContext(context.Background(), instance, method, args...)
+}
+
+// InvokeContext is like Invoke, but stops with a runtime error wrapping the
+// context's error if ctx is done before the method returns.
+func (r *Runtime) InvokeContext(ctx context.Context, instance *interpreter.Instance, method string, args ...any) (any, error) {
+	return r.interpreter.Invoke(ctx, instance, method, args...)
+}
@@ -0,0 +1,514 @@
+package lox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/DomBlack/lox/glox/pkg/errs"
+	"github.com/DomBlack/lox/glox/pkg/interpreter"
+)
+
+func TestRunCapturesOutput(t *testing.T) {
+	var stdout, stderr strings.Builder
+	r := New(WithStdout(&stdout), WithStderr(&stderr))
+
+	if err := r.Run(`print 1 + 2; print "a" + "b";`); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if got, want := stdout.String(), "3\n\"ab\"\n"; got != want {
+		t.Errorf("stdout = %q, want %q", got, want)
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("stderr = %q, want empty", stderr.String())
+	}
+}
+
+func TestRunReturnsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		runtime bool
+		stderr  string
+	}{
+		{"scan", `print 1 @;`, false, "[line 1] Error : Unexpected character.\n"},
+		{"resolve", `return 1;`, false, "[line 1] Error  at 'return': Cannot return from top-level code.\n"},
+		{"runtime", `print -"a";`, true, "Operand must be a number.\n[line 1] in script\n"},
+		{"break outside loop", `break;`, false, "[line 1] Error  at 'break': Cannot use 'break' outside of a loop.\n"},
+		{"continue in function", `while (true) { fun f() { continue; } }`, false, "[line 1] Error  at 'continue': Cannot use 'continue' outside of a loop.\n"},
+		{"undefined label", `outer: while (true) { break inner; }`, false, "[line 1] Error  at 'inner': Undefined label 'inner'.\n"},
+		{"optional chain on nil property", `class A {} var a = A(); a.b = nil; print a?.b.c;`, true, "Only instances have properties.\n[line 1] in script\n"},
+		{"increment non-number", `var a = "a"; a++;`, true, "Operand must be a number.\n[line 1] in script\n"},
+		{"compound assignment type error", `var a = "a"; a -= 1;`, true, "Operand must be a number.\n[line 1] in script\n"},
+		{"increment invalid target", `1++;`, false, "[line 1] Error  at '++': Invalid increment target.\n"},
+		{"bitwise non-integer", `print 1.5 & 1;`, true, "Operand must be an integer.\n[line 1] in script\n"},
+		{"bitwise non-number", `print ~"a";`, true, "Operand must be a number.\n[line 1] in script\n"},
+		{"negative shift", `print 1 << -1;`, true, "Shift count must not be negative.\n[line 1] in script\n"},
+		{"label without loop", `outer: print 1;`, false, "[line 1] Error  at 'print': Expect loop after label.\n"},
+		{"list index out of range", `var xs = [1]; print xs[1];`, true, "List index out of range.\n[line 1] in script\n"},
+		{"list index not integer", `var xs = [1]; xs[0.5] = 2;`, true, "List index must be an integer.\n[line 1] in script\n"},
+		{"index non-list", `print 1[0];`, true, "Only lists and maps can be indexed.\n[line 1] in script\n"},
+		{"pop empty list", `[].pop();`, true, "Cannot pop from an empty list.\n[line 1] in script\n"},
+		{"len of number", `len(1);`, true, "Argument 1 must be a list, map or string.\n[line 1] in script\n"},
+		{"undefined map key", `var m = {"a": 1}; print m["b"];`, true, "Undefined key \"b\".\n[line 1] in script\n"},
+		{"unterminated map", `var m = {"a": 1;`, false, "[line 1] Error  at ';': Expect '}' after map entries.\n"},
+		{"unterminated list", `print [1, 2;`, false, "[line 1] Error  at ';': Expect ']' after list elements.\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr strings.Builder
+			err := New(WithStdout(&stdout), WithStderr(&stderr)).Run(tt.source)
+			if err == nil {
+				t.Fatal("Run() error = nil, want error")
+			}
+
+			var runtimeErr *errs.RuntimeError
+			if got := errors.As(err, &runtimeErr); got != tt.runtime {
+				t.Errorf("errors.As(*errs.RuntimeError) = %v, want %v", got, tt.runtime)
+			}
+			if got := stderr.String(); got != tt.stderr {
+				t.Errorf("stderr = %q, want %q", got, tt.stderr)
+			}
+		})
+	}
+}
+
+func TestRuntimesAreIsolated(t *testing.T) {
+	var wg sync.WaitGroup
+	for n := 0; n < 4; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+
+			var stdout strings.Builder
+			r := New(WithStdout(&stdout))
+			source := fmt.Sprintf(`var x = %d; for (var i = 0; i < 100; i = i + 1) { x = x + 1; } print x;`, n)
+			if err := r.Run(source); err != nil {
+				t.Errorf("Run() error = %v", err)
+			}
+
+			if got, want := stdout.String(), fmt.Sprintf("%d\n", n+100); got != want {
+				t.Errorf("stdout = %q, want %q", got, want)
+			}
+		}(n)
+	}
+	wg.Wait()
+
+	var stderr strings.Builder
+	if err := New(WithStderr(&stderr)).Run(`print x;`); err == nil {
+		t.Errorf("Run() error = nil, want undefined variable; stderr = %q", stderr.String())
+	}
+}
+
+func TestDefineNative(t *testing.T) {
+	var stdout, stderr strings.Builder
+	r := New(WithStdout(&stdout), WithStderr(&stderr))
+	r.Define("sum", interpreter.NewNative("sum", interpreter.Variadic, func(args interpreter.Args) (any, error) {
+		total := 0.0
+		for i := range args {
+			n, err := args.NumberAt(i)
+			if err != nil {
+				return nil, err
+			}
+			total += n
+		}
+		return total, nil
+	}))
+
+	err := r.Run("print sum(1, 2, 3);\nprint sum;\nprint sum(1, \"2\");")
+	if err == nil {
+		t.Fatal("Run() error = nil, want error")
+	}
+
+	if got, want := stdout.String(), "6\n<native fn sum>\n"; got != want {
+		t.Errorf("stdout = %q, want %q", got, want)
+	}
+	if got, want := stderr.String(), "Argument 2 must be a number.\n[line 3] in script\n"; got != want {
+		t.Errorf("stderr = %q, want %q", got, want)
+	}
+}
+
+func TestCallFromGo(t *testing.T) {
+	r := New(WithStderr(&strings.Builder{}))
+	err := r.Run(`
+fun add(a, b) { return a + b; }
+class Counter {
+  init(start) { this.count = start; }
+  inc(by) { this.count = this.count + by; return this.count; }
+}
+var counter = Counter(10);
+`)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	add, ok := r.Get("add")
+	if !ok {
+		t.Fatal("Get(add) not defined")
+	}
+	if got, err := r.Call(add, 1.0, 2.0); err != nil || got != 3.0 {
+		t.Errorf("Call(add, 1, 2) = %v, %v; want 3, nil", got, err)
+	}
+	if got, err := r.Call(add, 1, int64(2)); err != nil || got != 3.0 {
+		t.Errorf("Call(add, int 1, int64 2) = %v, %v; want 3, nil", got, err)
+	}
+	if got, err := r.Call(add, uint8(1), float32(0.5)); err != nil || got != 1.5 {
+		t.Errorf("Call(add, uint8 1, float32 0.5) = %v, %v; want 1.5, nil", got, err)
+	}
+	if _, err := r.Call(add, 1.0, "a"); err == nil || !strings.HasPrefix(err.Error(), "Operands must be two numbers or two strings.") {
+		t.Errorf("Call(add, 1, a) error = %v, want operand error", err)
+	}
+	if _, err := r.Call(add, 1.0); err == nil || err.Error() != "Expected 2 arguments but got 1." {
+		t.Errorf("Call(add, 1) error = %v, want arity error", err)
+	}
+
+	counter, _ := r.Get("counter")
+	instance, ok := counter.(*interpreter.Instance)
+	if !ok {
+		t.Fatalf("Get(counter) = %T, want *interpreter.Instance", counter)
+	}
+	if got, err := r.Invoke(instance, "inc", 5.0); err != nil || got != 15.0 {
+		t.Errorf("Invoke(inc, 5) = %v, %v; want 15, nil", got, err)
+	}
+	if got, err := r.Invoke(instance, "inc", 5); err != nil || got != 20.0 {
+		t.Errorf("Invoke(inc, int 5) = %v, %v; want 20, nil", got, err)
+	}
+	if _, err := r.Invoke(instance, "dec"); err == nil || err.Error() != "Undefined property 'dec'." {
+		t.Errorf("Invoke(dec) error = %v, want undefined property", err)
+	}
+}
+
+type point struct {
+	X, Y  float64
+	Label string `lox:"label"`
+	Hits  int
+}
+
+func (p *point) Move(dx, dy float64) *point {
+	p.X += dx
+	p.Y += dy
+	return p
+}
+
+func (p *point) Coords() []float64 {
+	return []float64{p.X, p.Y}
+}
+
+func (p *point) Hit(n int) error {
+	if n < 0 {
+		return errors.New("Cannot hit a negative number of times.")
+	}
+	p.Hits += n
+	return nil
+}
+
+func TestGoInstance(t *testing.T) {
+	p := &point{X: 1, Y: 2, Label: "origin"}
+	instance, err := interpreter.NewGoInstance(p)
+	if err != nil {
+		t.Fatalf("NewGoInstance() error = %v", err)
+	}
+
+	var stdout, stderr strings.Builder
+	r := New(WithStdout(&stdout), WithStderr(&stderr))
+	r.Define("p", instance)
+
+	err = r.Run(`
+print p.label;
+p.label = "moved";
+print p.Move(2, 3).X;
+print p.Move(0, 0) == p;
+p.Hit(2);
+print p;
+`)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got, want := stdout.String(), "\"origin\"\n3\ntrue\npoint instance\n"; got != want {
+		t.Errorf("stdout = %q, want %q", got, want)
+	}
+	if want := (point{X: 3, Y: 5, Label: "moved", Hits: 2}); *p != want {
+		t.Errorf("p = %+v, want %+v", *p, want)
+	}
+
+	for source, want := range map[string]string{
+		"p.Hits = 1.5;":                  "Cannot set 'Hits': must be an integer that fits in int.\n[line 1] in script",
+		"p.Hits = 1e300;":                "Cannot set 'Hits': must be an integer that fits in int.\n[line 1] in script",
+		"p.Hits = 0 / 0;":                "Cannot set 'Hits': must be an integer that fits in int.\n[line 1] in script",
+		"p.Hits = 1 / 0;":                "Cannot set 'Hits': must be an integer that fits in int.\n[line 1] in script",
+		"p.Hit(2 ** 63);":                "Argument 1 to 'Hit' must be an integer that fits in int.\n[line 1] in script",
+		"p.Move(\"a\", 1);":              "Argument 1 to 'Move' must be a number.\n[line 1] in script",
+		"p.Hit(-1);":                     "Cannot hit a negative number of times.\n[line 1] in script",
+		"p.missing;":                     "Undefined property 'missing'.\n[line 1] in script",
+		"var m = {}; m[p.Coords()] = 1;": "Invalid map key.\n[line 1] in script",
+		"print {p.Coords(): 1};":         "Invalid map key.\n[line 1] in script",
+	} {
+		if err := r.Run(source); err == nil || err.Error() != want {
+			t.Errorf("Run(%q) error = %v, want %q", source, err, want)
+		}
+	}
+}
+
+func TestBudgets(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []interpreter.Option
+		source  string
+		want    error
+	}{
+		{"steps", []interpreter.Option{interpreter.WithMaxSteps(100)}, `while (true) {}`, interpreter.ErrStepLimitExceeded},
+		{"depth", []interpreter.Option{interpreter.WithMaxCallDepth(10)}, `fun f(n) { return f(n + 1); } f(0);`, interpreter.ErrStackOverflow},
+		{"timeout", []interpreter.Option{interpreter.WithTimeout(10 * time.Millisecond)}, `while (true) {}`, context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(WithStderr(&strings.Builder{}), WithInterpreterOptions(tt.options...))
+			if err := r.Run(tt.source); !errors.Is(err, tt.want) {
+				t.Errorf("Run() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	t.Run("cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		r := New(WithStderr(&strings.Builder{}))
+		if err := r.RunContext(ctx, `while (true) {}`); !errors.Is(err, context.Canceled) {
+			t.Errorf("RunContext() error = %v, want %v", err, context.Canceled)
+		}
+	})
+
+	t.Run("cancel callback", func(t *testing.T) {
+		r := New(WithStderr(&strings.Builder{}))
+		if err := r.Run(`fun spin() { while (true) {} } class Spinner { spin() { while (true) {} } }`); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+
+		spin, _ := r.Get("spin")
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if _, err := r.CallContext(ctx, spin); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("CallContext() error = %v, want %v", err, context.DeadlineExceeded)
+		}
+
+		class, _ := r.Get("Spinner")
+		instance, err := r.Call(class)
+		if err != nil {
+			t.Fatalf("Call(Spinner) error = %v", err)
+		}
+		ctx, cancel = context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+		if _, err := r.InvokeContext(ctx, instance.(*interpreter.Instance), "spin"); !errors.Is(err, context.Canceled) {
+			t.Errorf("InvokeContext() error = %v, want %v", err, context.Canceled)
+		}
+	})
+
+	t.Run("steps trace", func(t *testing.T) {
+		var stderr strings.Builder
+		r := New(WithStderr(&stderr), WithInterpreterOptions(interpreter.WithMaxSteps(100)))
+		r.Run("var a = 1;\nwhile (true) {}")
+		if got, want := stderr.String(), "Step limit exceeded.\n[line 2] in script\n"; got != want {
+			t.Errorf("stderr = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("steps reentrant", func(t *testing.T) {
+		// Calling back into the interpreter must not reset the step budget.
+		var r *Runtime
+		r = New(WithStderr(&strings.Builder{}), WithInterpreterOptions(interpreter.WithMaxSteps(100), interpreter.WithTimeout(time.Second)))
+		r.Define("reenter", interpreter.NewNative("reenter", 1, func(args interpreter.Args) (any, error) {
+			_, err := r.Call(args[0])
+			return nil, err
+		}))
+
+		if err := r.Run(`fun f() {} while (true) { reenter(f); }`); !errors.Is(err, interpreter.ErrStepLimitExceeded) {
+			t.Errorf("Run() error = %v, want %v", err, interpreter.ErrStepLimitExceeded)
+		}
+	})
+}
+
+func TestStackOverflow(t *testing.T) {
+	var stderr strings.Builder
+	r := New(WithStderr(&stderr))
+	err := r.Run("fun f() {\n  f();\n}\nf();")
+	if !errors.Is(err, interpreter.ErrStackOverflow) {
+		t.Fatalf("Run() error = %v, want %v", err, interpreter.ErrStackOverflow)
+	}
+	if got := stderr.String(); !strings.HasPrefix(got, "Stack overflow.\n[line 2] in f()\n") || !strings.HasSuffix(got, "more frames ...\n[line 2] in f()\n[line 2] in f()\n[line 2] in f()\n[line 2] in f()\n[line 2] in f()\n[line 2] in f()\n[line 2] in f()\n[line 4] in script\n") {
+		t.Errorf("stderr = %q, want elided stack trace", got)
+	}
+
+	// The interpreter is still usable after unwinding.
+	if err := r.Run(`f;`); err != nil {
+		t.Errorf("Run() after overflow error = %v", err)
+	}
+}
+
+func TestStackTrace(t *testing.T) {
+	var stderr strings.Builder
+	r := New(WithStderr(&stderr))
+	err := r.Run(`class Greeter {
+  greet(name) {
+    return "Hello " + name;
+  }
+}
+fun run(greeter) {
+  return greeter.greet(nil);
+}
+run(Greeter());`)
+
+	var runtimeErr *errs.RuntimeError
+	if !errors.As(err, &runtimeErr) {
+		t.Fatalf("Run() error = %v, want runtime error", err)
+	}
+
+	want := []errs.Frame{{Function: "greet", Class: "Greeter", Line: 3}, {Function: "run", Line: 7}, {Line: 9}}
+	if fmt.Sprint(runtimeErr.Trace) != fmt.Sprint(want) {
+		t.Errorf("Trace = %v, want %v", runtimeErr.Trace, want)
+	}
+	if got, want := stderr.String(), "Operands must be two numbers or two strings.\n[line 3] in Greeter.greet()\n[line 7] in run()\n[line 9] in script\n"; got != want {
+		t.Errorf("stderr = %q, want %q", got, want)
+	}
+
+	// Functions called from Go have no script frame.
+	run, _ := r.Get("run")
+	if _, err := r.Call(run, nil); err == nil || err.Error() != "Only instances have properties.\n[line 7] in run()" {
+		t.Errorf("Call() error = %v, want trace ending at run", err)
+	}
+}
+
+func TestPrograms(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		stdout string
+	}{
+		{"interpolation", `var name = "Bob"; var age = 41; print "Hello ${name}, you are ${age + 1}";`, "\"Hello Bob, you are 42\"\n"},
+		{"interpolation values", `class A {} print "${nil} ${true} ${1.5} ${A}";`, "\"<nil> true 1.5 A\"\n"},
+		{"nested interpolation", `var f = "x"; print "a ${ "b ${f} c" } d";`, "\"a b x c d\"\n"},
+		{"escaped interpolation", `print "\${x}";`, "\"${x}\"\n"},
+		{"break", `var i = 0; while (true) { if (i == 2) break; print i; i = i + 1; }`, "0\n1\n"},
+		{"continue runs increment", `for (var i = 0; i < 4; i = i + 1) { if (i == 1) continue; print i; }`, "0\n2\n3\n"},
+		{"continue in while", `var i = 0; while (i < 3) { i = i + 1; if (i == 2) continue; print i; }`, "1\n3\n"},
+		{"labelled break", `outer: for (var i = 0; i < 3; i = i + 1) { for (var j = 0; j < 3; j = j + 1) { if (j == 1) continue outer; if (i == 2) break outer; print "${i}${j}"; } }`, "\"00\"\n\"10\"\n"},
+		{"lambda", `fun apply(f, x) { return f(x); } print apply(fun (a) { return a + 1; }, 1);`, "2\n"},
+		{"lambda statement", `fun (x) { print x; }(5);`, "5\n"},
+		{"arrow function", `var add = (a, b) => a + b; print add(1, 2); print (() => "none")();`, "3\n\"none\"\n"},
+		{"arrow block", `var f = (a) => { if (a) return "yes"; return "no"; }; print f(true); print f(false);`, "\"yes\"\n\"no\"\n"},
+		{"arrow closure", `fun counter() { var n = 0; return () => n = n + 1; } var c = counter(); c(); print c();`, "2\n"},
+		{"anonymous function", `print fun () {}; print (a) => a;`, "<fn anonymous>\n<fn anonymous>\n"},
+		{"grouping is not arrow", `var a = 1; print (a); print (a) + 1;`, "1\n2\n"},
+		{"conditional", `print true ? 1 : 2; print nil ? 1 : false ? 2 : 3;`, "1\n3\n"},
+		{"conditional short-circuits", `var a = 0; true ? a = 1 : (a = 2); print a;`, "1\n"},
+		{"coalesce", `print nil ?? "default"; print false ?? "default"; print 0 ?? 1;`, "\"default\"\nfalse\n0\n"},
+		{"optional chaining", `class A { m() { return "m"; } } var a = A(); a.b = A(); var n = nil; print a?.m(); print n?.m(); print n?.b.c.d(); print a.b?.m();`, "\"m\"\n<nil>\n<nil>\n\"m\"\n"},
+		{"compound assignment", `var a = 10; a += 5; print a; a -= 3; print a; a *= 2; print a; a /= 4; print a; a %= 4; print a; var s = "a"; s += "b"; print s;`, "15\n12\n24\n6\n2\n\"ab\"\n"},
+		{"compound assignment to local", `{ var a = 1; fun f() { a += 1; } f(); f(); print a; }`, "3\n"},
+		{"compound assignment order", `class A {} var o = A(); o.x = 1; fun f() { o.x = 10; return 1; } o.x += f(); print o.x; var x = 1; fun g() { x = 10; return 1; } x += g(); print x;`, "2\n2\n"},
+		{"compound assignment value", `var a = 1; print a += 2; print a;`, "3\n3\n"},
+		{"increment", `var a = 1; print a++; print a; print ++a; print a--; print --a; print a;`, "1\n2\n3\n3\n1\n1\n"},
+		{"increment in loop", `for (var i = 0; i < 3; i++) print i;`, "0\n1\n2\n"},
+		{"property update", `class A {} var a = A(); a.n = 1; a.n += 2; print a.n; print a.n++; print ++a.n;`, "3\n3\n5\n"},
+		{"object evaluated once", `class A {} var a = A(); a.n = 0; var calls = 0; fun get() { calls++; return a; } get().n += 1; get().n++; ++get().n; print a.n; print calls;`, "3\n3\n"},
+		{"modulo", `print 7 % 3; print -7 % 3; print 7.5 % 2;`, "1\n-1\n1.5\n"},
+		{"exponent", `print 2 ** 10; print 2 ** 3 ** 2; print -2 ** 2; print 2 ** -1;`, "1024\n512\n-4\n0.5\n"},
+		{"floor division", `print 7 ~/ 2; print -7 ~/ 2; print 7.5 ~/ 2.5; var a = 9; a = a ~/ 2 * 2; print a;`, "3\n-4\n3\n8\n"},
+		{"bitwise", `print 12 & 10; print 12 | 3; print 6 ^ 3; print ~5; print 1 << 4; print -16 >> 2;`, "8\n15\n5\n-6\n16\n-4\n"},
+		{"bitwise precedence", `print 1 | 2 ^ 3 & 4; print 1 + 1 << 2; print (3 & 1) == 1;`, "3\n8\ntrue\n"},
+		{"list literal", `print [1, "a", nil, [true],]; print [];`, "[1, \"a\", <nil>, [true]]\n[]\n"},
+		{"list indexing", `var xs = [1, 2, 3]; print xs[0]; print xs[-1]; xs[1] = 5; xs[0] += 10; xs[2]++; print xs;`, "1\n3\n[11, 5, 4]\n"},
+		{"list compound assignment order", `var xs = [1]; fun f() { xs[0] = 10; return 1; } xs[0] += f(); print xs;`, "[2]\n"},
+		{"list slicing", `var xs = [1, 2, 3, 4]; print xs[1:3]; print xs[:-1]; print xs[2:]; print xs[:]; print xs[-10:10]; print xs[3:1];`, "[2, 3]\n[1, 2, 3]\n[3, 4]\n[1, 2, 3, 4]\n[1, 2, 3, 4]\n[]\n"},
+		{"list methods", `var xs = [1]; xs.push(2); print xs.pop(); xs.insert(0, 0); xs.insert(2, 3); print xs; print xs.remove(-1); print xs.contains(1); print xs.contains(3);`, "2\n[0, 1, 3]\n3\ntrue\nfalse\n"},
+		{"list containing itself", `var xs = [1]; xs.push(xs); print xs;`, "[1, [...]]\n"},
+		{"len", `print len([1, 2]); print len("héllo");`, "2\n5\n"},
+		{"map literal", `print {"a": 1, 2: [true], nil: nil,}; print {};`, "{\"a\": 1, 2: [true], <nil>: <nil>}\n{}\n"},
+		{"map indexing", `var m = {"a": 1}; m["b"] = 2; m["a"] += 10; m["b"]++; print m["a"]; print m;`, "11\n{\"a\": 11, \"b\": 3}\n"},
+		{"map methods", `var m = {"a": 1, "b": 2}; print m.keys(); print m.values(); print m.has("a"); print m.delete("a"); print m.delete("a"); print m.has("a"); print len(m);`, "[\"a\", \"b\"]\n[1, 2]\ntrue\ntrue\nfalse\nfalse\n1\n"},
+		{"map insertion order", `var m = {"c": 1, "a": 2}; m["b"] = 3; m["c"] = 4; m.delete("a"); m["a"] = 5; print m;`, "{\"c\": 4, \"b\": 3, \"a\": 5}\n"},
+		{"map key equality", `class A {} var a = A(); var m = {1: "one", true: "t", nil: "n"}; m[a] = "a"; m[A()] = "other"; print m[1.0]; print m[true]; print m[nil]; print m[a]; print len(m);`, "\"one\"\n\"t\"\n\"n\"\n\"a\"\n5\n"},
+		{"map at statement start", `{"a": 1}["a"]; { var a = 1; print a; }`, "1\n"},
+		{"map with identifier key at statement start", `var k = "a"; {k: 1}[k]; { outer: while (true) { break outer; } } print "ok";`, "\"ok\"\n"},
+		{"map as arrow function body", `var f = () => {"a": 1}; var g = (k) => {k: 2}; var h = () => { return 3; }; print f(); print g("b"); print h();`, "{\"a\": 1}\n{\"b\": 2}\n3\n"},
+		{"map containing itself", `var m = {}; m["m"] = m; print m;`, "{\"m\": {...}}\n"},
+		{"break closes scopes", `var a = "global"; while (true) { var a = "local"; break; } print a;`, "\"global\"\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr strings.Builder
+			r := New(WithStdout(&stdout), WithStderr(&stderr))
+			if err := r.Run(tt.source); err != nil {
+				t.Fatalf("Run() error = %v\n%s", err, stderr.String())
+			}
+			if got := stdout.String(); got != tt.stdout {
+				t.Errorf("stdout = %q, want %q", got, tt.stdout)
+			}
+		})
+	}
+}
+
+func TestRunReader(t *testing.T) {
+	var stdout, stderr strings.Builder
+	r := New(WithStdout(&stdout), WithStderr(&stderr), WithErrorFormat(errs.FormatText))
+
+	src := iotest.HalfReader(strings.NewReader("var a = 1;\nprint a + 1;"))
+	if err := r.RunReader(context.Background(), "stdin", src); err != nil {
+		t.Fatalf("RunReader() error = %v", err)
+	}
+	if got, want := stdout.String(), "2\n"; got != want {
+		t.Errorf("stdout = %q, want %q", got, want)
+	}
+
+	// Diagnostics still show the source read from the reader.
+	err := r.RunReader(context.Background(), "stdin", strings.NewReader("var a = 1;\nprint a +;"))
+	if err == nil {
+		t.Fatal("RunReader() error = nil, want syntax error")
+	}
+	if got := stderr.String(); !strings.Contains(got, "stdin:2:10") || !strings.Contains(got, "print a +;") {
+		t.Errorf("stderr = %q, want snippet of line 2", got)
+	}
+}
+
+func TestRunReaderKeepsSourceOnlyForSnippets(t *testing.T) {
+	tests := []struct {
+		format errs.Format
+		keep   bool
+		stderr string
+	}{
+		{errs.FormatPlain, false, "[line 2] Error  at ';': Expect expression.\n"},
+		{errs.FormatJSON, false, `{"file":"stdin","line":2,"column":10,"start":20,"end":21,"severity":"error","code":"E0101","message":"Expect expression."}` + "\n"},
+		{errs.FormatText, true, ""},
+	}
+
+	for _, tt := range tests {
+		var stderr strings.Builder
+		r := New(WithStderr(&stderr), WithErrorFormat(tt.format))
+
+		src := strings.NewReader("var a = 1;\nprint a +;")
+		reader, source := r.keepSource(src)
+		if kept := source != nil; kept != tt.keep {
+			t.Errorf("keepSource(%d) kept source = %v, want %v", tt.format, kept, tt.keep)
+		}
+		if !tt.keep && reader != io.Reader(src) {
+			t.Errorf("keepSource(%d) wrapped the reader, want it returned as it is", tt.format)
+		}
+
+		if err := r.RunReader(context.Background(), "stdin", src); err == nil {
+			t.Fatalf("RunReader(%d) error = nil, want syntax error", tt.format)
+		}
+		if tt.stderr != "" && stderr.String() != tt.stderr {
+			t.Errorf("RunReader(%d) stderr = %q, want %q", tt.format, stderr.String(), tt.stderr)
+		}
+	}
+}
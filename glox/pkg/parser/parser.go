@@ -6,31 +6,88 @@ import (
 	"github.com/DomBlack/lox/glox/pkg/token"
 )
 
+// TokenSource is a stream of tokens, such as a *scanner.Scanner. Once it has
+// returned an EOF token it must keep returning EOF.
+type TokenSource interface {
+	Next() token.Token
+}
+
 type Parser struct {
-	tokens  []*token.Token
+	source  TokenSource
+	tokens  []token.Token // tokens read from the source which may still be needed
 	current int
+	errors  errs.Diagnostics
+
+	// panicMode is set after a syntax error, to suppress the errors which
+	// cascade from it until the parser has synchronized.
+	panicMode bool
 }
 
 func New(tokens []*token.Token) *Parser {
+	return NewStream(&sliceSource{tokens: tokens})
+}
+
+// NewStream creates a parser which reads tokens from source as it needs them.
+func NewStream(source TokenSource) *Parser {
 	return &Parser{
-		tokens: tokens,
+		source: source,
+	}
+}
+
+// sliceSource is a TokenSource over tokens which have already been scanned.
+type sliceSource struct {
+	tokens []*token.Token
+}
+
+func (s *sliceSource) Next() token.Token {
+	if len(s.tokens) == 0 {
+		return token.Token{Type: token.EOF}
+	}
+
+	t := s.tokens[0]
+	if t.Type != token.EOF {
+		s.tokens = s.tokens[1:]
 	}
+	return *t
 }
 
-func (p *Parser) Parse() []ast.Stmt {
+// Parse parses the whole program, reporting every independent syntax error.
+// Statements and expressions which could not be parsed are represented by
+// ast.BadStmt and ast.BadExpr nodes in the returned statements.
+func (p *Parser) Parse() ([]ast.Stmt, errs.Diagnostics) {
 	var statements []ast.Stmt
 	for !p.isAtEnd() {
 		statements = append(statements, p.declaration())
+
+		// Only the previous token can be needed once a declaration has been
+		// parsed, so the rest can be released.
+		p.tokens = p.tokens[p.current-1:]
+		p.current = 1
 	}
 
-	return statements
+	return statements, p.errors
 }
 
-func (p *Parser) declaration() ast.Stmt {
+func (p *Parser) declaration() (stmt ast.Stmt) {
+	start := p.current
+	defer func() {
+		if r := recover(); r != nil {
+			if r != ErrParseError {
+				panic(r)
+			}
+
+			p.synchronize(start)
+			stmt = &ast.BadStmt{From: &p.tokens[start], To: p.previous()}
+		} else if p.panicMode {
+			p.synchronize(start)
+		}
+	}()
+
 	switch {
 	case p.match(token.CLASS):
 		return p.classDeclaration()
-	case p.match(token.FUN):
+	case p.check(token.FUN) && p.lookahead(1).Type == token.IDENTIFIER:
+		p.advance()
 		return p.function("function")
 	case p.match(token.VAR):
 		return p.varDeclaration()
@@ -40,6 +97,7 @@ func (p *Parser) declaration() ast.Stmt {
 }
 
 func (p *Parser) classDeclaration() ast.Stmt {
+	keyword := p.previous()
 	name := p.consume(token.IDENTIFIER, "Expect class name.")
 
 	var superclass *ast.VariableExpr
@@ -55,18 +113,34 @@ func (p *Parser) classDeclaration() ast.Stmt {
 		methods = append(methods, p.function("method"))
 	}
 
-	p.consume(token.RIGHT_BRACE, "Expect '}' after class body.")
-	return &ast.ClassStmt{Name: name, Superclass: superclass, Methods: methods}
+	rightBrace := p.consume(token.RIGHT_BRACE, "Expect '}' after class body.")
+	return &ast.ClassStmt{Keyword: keyword, Name: name, Superclass: superclass, Methods: methods, RightBrace: rightBrace}
 }
 
 func (p *Parser) function(kind string) *ast.FunctionStmt {
+	// Methods are declared without the 'fun' keyword.
+	var keyword *token.Token
+	if p.previous().Type == token.FUN {
+		keyword = p.previous()
+	}
+
 	name := p.consume(token.IDENTIFIER, "Expect "+kind+" name.")
 	p.consume(token.LEFT_PAREN, "Expect '(' after "+kind+" name.")
+	params := p.parameters()
+
+	p.consume(token.LEFT_BRACE, "Expect '{' before "+kind+" body.")
+	body, rightBrace := p.block()
+
+	return &ast.FunctionStmt{Keyword: keyword, Name: name, Params: params, Body: body, RightBrace: rightBrace}
+}
+
+// parameters parses a function's parameter list after its opening parenthesis.
+func (p *Parser) parameters() []*token.Token {
 	var params []*token.Token
 	if !p.check(token.RIGHT_PAREN) {
 		for {
 			if len(params) >= 255 {
-				p.error(p.peek(), "Can't have more than 255 parameters.")
+				p.error(p.peek(), errs.TooManyParameters, "Can't have more than 255 parameters.")
 			}
 
 			params = append(params, p.consume(token.IDENTIFIER, "Expect parameter name."))
@@ -77,13 +151,56 @@ func (p *Parser) function(kind string) *ast.FunctionStmt {
 	}
 	p.consume(token.RIGHT_PAREN, "Expect ')' after parameters.")
 
-	p.consume(token.LEFT_BRACE, "Expect '{' before "+kind+" body.")
-	body := p.block()
+	return params
+}
+
+// lambda parses an anonymous function expression after its 'fun' keyword.
+func (p *Parser) lambda() ast.Expr {
+	keyword := p.previous()
+	leftParen := p.consume(token.LEFT_PAREN, "Expect '(' after 'fun'.")
+	params := p.parameters()
+
+	p.consume(token.LEFT_BRACE, "Expect '{' before function body.")
+	body, rightBrace := p.block()
+
+	return &ast.FunctionExpr{Keyword: keyword, LeftParen: leftParen, Params: params, Body: body, RightBrace: rightBrace}
+}
+
+// isArrowFunction reports whether the tokens from the current '(' are the
+// parameter list of an arrow function, such as "(a, b) =>".
+func (p *Parser) isArrowFunction() bool {
+	n := 1
+	if p.lookahead(n).Type == token.IDENTIFIER {
+		n++
+		for p.lookahead(n).Type == token.COMMA && p.lookahead(n+1).Type == token.IDENTIFIER {
+			n += 2
+		}
+	}
+
+	return p.lookahead(n).Type == token.RIGHT_PAREN && p.lookahead(n+1).Type == token.ARROW
+}
+
+// arrowFunction parses an arrow function after its opening parenthesis. The
+// body is either a block, or an expression which the function returns, which
+// may be a map literal.
+func (p *Parser) arrowFunction() ast.Expr {
+	leftParen := p.previous()
+	params := p.parameters()
+	arrow := p.consume(token.ARROW, "Expect '=>' after parameters.")
+
+	if p.check(token.LEFT_BRACE) && !p.isMapLiteral() {
+		p.advance()
+		body, rightBrace := p.block()
+		return &ast.FunctionExpr{LeftParen: leftParen, Params: params, Body: body, RightBrace: rightBrace}
+	}
 
-	return &ast.FunctionStmt{Name: name, Params: params, Body: body}
+	value := p.expression()
+	body := []ast.Stmt{&ast.ReturnStmt{Keyword: arrow, Value: value}}
+	return &ast.FunctionExpr{LeftParen: leftParen, Params: params, Body: body}
 }
 
 func (p *Parser) varDeclaration() ast.Stmt {
+	keyword := p.previous()
 	name := p.consume(token.IDENTIFIER, "Expect variable name.")
 
 	var initializer ast.Expr
@@ -91,14 +208,20 @@ func (p *Parser) varDeclaration() ast.Stmt {
 		initializer = p.expression()
 	}
 
-	p.consume(token.SEMICOLON, "Expect ';' after variable declaration.")
-	return &ast.VarStmt{Name: name, Initializer: initializer}
+	semicolon := p.consume(token.SEMICOLON, "Expect ';' after variable declaration.")
+	return &ast.VarStmt{Keyword: keyword, Name: name, Initializer: initializer, Semicolon: semicolon}
 }
 
 func (p *Parser) statement() ast.Stmt {
 	switch {
+	case p.match(token.BREAK):
+		return p.breakStatement()
+	case p.match(token.CONTINUE):
+		return p.continueStatement()
 	case p.match(token.FOR):
-		return p.forStatement()
+		return p.forStatement(nil)
+	case p.check(token.IDENTIFIER) && p.lookahead(1).Type == token.COLON:
+		return p.labelledStatement()
 	case p.match(token.IF):
 		return p.ifStatement()
 	case p.match(token.PRINT):
@@ -106,15 +229,56 @@ func (p *Parser) statement() ast.Stmt {
 	case p.match(token.RETURN):
 		return p.returnStatement()
 	case p.match(token.WHILE):
-		return p.whileStatement()
-	case p.match(token.LEFT_BRACE):
-		return &ast.BlockStmt{Statements: p.block()}
+		return p.whileStatement(nil)
+	case p.check(token.LEFT_BRACE) && !p.isMapLiteral():
+		leftBrace := p.advance()
+		statements, rightBrace := p.block()
+		return &ast.BlockStmt{LeftBrace: leftBrace, Statements: statements, RightBrace: rightBrace}
 	default:
 		return p.expressionStatement()
 	}
 }
 
-func (p *Parser) forStatement() ast.Stmt {
+func (p *Parser) breakStatement() ast.Stmt {
+	keyword := p.previous()
+	var label *token.Token
+	if p.match(token.IDENTIFIER) {
+		label = p.previous()
+	}
+
+	semicolon := p.consume(token.SEMICOLON, "Expect ';' after 'break'.")
+	return &ast.BreakStmt{Keyword: keyword, Label: label, Semicolon: semicolon}
+}
+
+func (p *Parser) continueStatement() ast.Stmt {
+	keyword := p.previous()
+	var label *token.Token
+	if p.match(token.IDENTIFIER) {
+		label = p.previous()
+	}
+
+	semicolon := p.consume(token.SEMICOLON, "Expect ';' after 'continue'.")
+	return &ast.ContinueStmt{Keyword: keyword, Label: label, Semicolon: semicolon}
+}
+
+// labelledStatement parses a loop with a label, such as "outer: while (...)",
+// which break and continue statements within it can refer to.
+func (p *Parser) labelledStatement() ast.Stmt {
+	label := p.advance()
+	p.advance() // the colon
+
+	switch {
+	case p.match(token.FOR):
+		return p.forStatement(label)
+	case p.match(token.WHILE):
+		return p.whileStatement(label)
+	default:
+		panic(p.error(p.peek(), errs.ExpectedToken, "Expect loop after label."))
+	}
+}
+
+func (p *Parser) forStatement(label *token.Token) ast.Stmt {
+	keyword := p.previous()
 	p.consume(token.LEFT_PAREN, "Expect '(' after 'for'.")
 
 	var initializer ast.Stmt
@@ -140,17 +304,12 @@ func (p *Parser) forStatement() ast.Stmt {
 
 	body := p.statement()
 
-	if increment != nil {
-		body = &ast.BlockStmt{Statements: []ast.Stmt{
-			body,
-			&ast.ExpressionStmt{Expression: increment},
-		}}
-	}
-
+	// The increment is kept separate from the body, rather than appended to
+	// it, so that it still runs after a continue statement.
 	if condition == nil {
 		condition = &ast.LiteralExpr{Value: true}
 	}
-	body = &ast.WhileStmt{Condition: condition, Body: body}
+	body = &ast.WhileStmt{Label: label, Keyword: keyword, Condition: condition, Body: body, Increment: increment}
 
 	if initializer != nil {
 		body = &ast.BlockStmt{Statements: []ast.Stmt{
@@ -163,6 +322,7 @@ func (p *Parser) forStatement() ast.Stmt {
 }
 
 func (p *Parser) ifStatement() ast.Stmt {
+	keyword := p.previous()
 	p.consume(token.LEFT_PAREN, "Expect '(' after 'if'.")
 	condition := p.expression()
 	p.consume(token.RIGHT_PAREN, "Expect ')' after if condition.")
@@ -174,6 +334,7 @@ func (p *Parser) ifStatement() ast.Stmt {
 	}
 
 	return &ast.IfStmt{
+		Keyword:    keyword,
 		Condition:  condition,
 		ThenBranch: thenBranch,
 		ElseBranch: elseBranch,
@@ -181,9 +342,10 @@ func (p *Parser) ifStatement() ast.Stmt {
 }
 
 func (p *Parser) printStatement() ast.Stmt {
+	keyword := p.previous()
 	value := p.expression()
-	p.consume(token.SEMICOLON, "Expect ';' after value.")
-	return &ast.PrintStmt{Expression: value}
+	semicolon := p.consume(token.SEMICOLON, "Expect ';' after value.")
+	return &ast.PrintStmt{Keyword: keyword, Expression: value, Semicolon: semicolon}
 }
 
 func (p *Parser) returnStatement() ast.Stmt {
@@ -193,44 +355,53 @@ func (p *Parser) returnStatement() ast.Stmt {
 		value = p.expression()
 	}
 
-	p.consume(token.SEMICOLON, "Expect ';' after return value.")
-	return &ast.ReturnStmt{Keyword: keyword, Value: value}
+	semicolon := p.consume(token.SEMICOLON, "Expect ';' after return value.")
+	return &ast.ReturnStmt{Keyword: keyword, Value: value, Semicolon: semicolon}
 }
 
-func (p *Parser) whileStatement() ast.Stmt {
+func (p *Parser) whileStatement(label *token.Token) ast.Stmt {
+	keyword := p.previous()
 	p.consume(token.LEFT_PAREN, "Expect '(' after 'while'.")
 	condition := p.expression()
 	p.consume(token.RIGHT_PAREN, "Expect ')' after condition.")
 	body := p.statement()
 
-	return &ast.WhileStmt{Condition: condition, Body: body}
+	return &ast.WhileStmt{Label: label, Keyword: keyword, Condition: condition, Body: body}
 }
 
 func (p *Parser) expressionStatement() ast.Stmt {
 	expr := p.expression()
-	p.consume(token.SEMICOLON, "Expect ';' after expression.")
-	return &ast.ExpressionStmt{Expression: expr}
+	semicolon := p.consume(token.SEMICOLON, "Expect ';' after expression.")
+	return &ast.ExpressionStmt{Expression: expr, Semicolon: semicolon}
 }
 
-func (p *Parser) block() []ast.Stmt {
+// block parses the statements of a block, after its opening brace, returning
+// them along with the closing brace.
+func (p *Parser) block() ([]ast.Stmt, *token.Token) {
 	var statements []ast.Stmt
 
 	for !p.check(token.RIGHT_BRACE) && !p.isAtEnd() {
 		statements = append(statements, p.declaration())
 	}
 
-	p.consume(token.RIGHT_BRACE, "Expect '}' after block.")
-	return statements
+	rightBrace := p.consume(token.RIGHT_BRACE, "Expect '}' after block.")
+	return statements, rightBrace
 }
 
 func (p *Parser) expression() (rtn ast.Expr) {
+	start := p.current
 	defer func() {
 		if r := recover(); r != nil {
-			if r == ErrParseError {
-				rtn = nil
-			} else {
+			if r != ErrParseError {
 				panic(r)
 			}
+
+			// Include the token which caused the error if nothing was consumed.
+			to := p.peek()
+			if p.current > start {
+				to = p.previous()
+			}
+			rtn = &ast.BadExpr{From: &p.tokens[start], To: to}
 		}
 	}()
 
@@ -238,20 +409,67 @@ func (p *Parser) expression() (rtn ast.Expr) {
 }
 
 func (p *Parser) assignment() ast.Expr {
-	expr := p.or()
+	expr := p.conditional()
 
-	if p.match(token.EQUAL) {
-		equals := p.previous()
+	if p.match(token.EQUAL, token.PLUS_EQUAL, token.MINUS_EQUAL, token.STAR_EQUAL, token.SLASH_EQUAL, token.PERCENT_EQUAL) {
+		operator := p.previous()
 		value := p.assignment()
 
 		switch v := expr.(type) {
 		case *ast.VariableExpr:
 			name := v.Name
-			return &ast.AssignExpr{Name: name, Value: value}
+			return &ast.AssignExpr{Name: name, Operator: operator, Value: value}
 		case *ast.GetExpr:
-			return &ast.SetExpr{Object: v.Object, Name: v.Name, Value: value}
+			return &ast.SetExpr{Object: v.Object, Name: v.Name, Operator: operator, Value: value}
+		case *ast.IndexExpr:
+			return &ast.IndexSetExpr{
+				Object:       v.Object,
+				LeftBracket:  v.LeftBracket,
+				Index:        v.Index,
+				RightBracket: v.RightBracket,
+				Operator:     operator,
+				Value:        value,
+			}
 		default:
-			errs.ErrorAtToken(equals, "Invalid assignment target.")
+			_ = p.error(operator, errs.InvalidAssignmentTarget, "Invalid assignment target.")
+		}
+	}
+
+	return expr
+}
+
+// conditional parses the right-associative "cond ? a : b" operator.
+func (p *Parser) conditional() ast.Expr {
+	expr := p.coalesce()
+
+	if p.match(token.QUESTION) {
+		question := p.previous()
+		thenBranch := p.expression()
+		colon := p.consume(token.COLON, "Expect ':' after then branch of conditional expression.")
+		elseBranch := p.conditional()
+		expr = &ast.ConditionalExpr{
+			Condition:  expr,
+			Question:   question,
+			ThenBranch: thenBranch,
+			Colon:      colon,
+			ElseBranch: elseBranch,
+		}
+	}
+
+	return expr
+}
+
+// coalesce parses the "??" operator, which binds more loosely than "or".
+func (p *Parser) coalesce() ast.Expr {
+	expr := p.or()
+
+	for p.match(token.QUESTION_QUESTION) {
+		operator := p.previous()
+		right := p.or()
+		expr = &ast.LogicalExpr{
+			Left:     expr,
+			Operator: operator,
+			Right:    right,
 		}
 	}
 
@@ -275,11 +493,11 @@ func (p *Parser) or() ast.Expr {
 }
 
 func (p *Parser) and() ast.Expr {
-	expr := p.equality()
+	expr := p.bitwiseOr()
 
 	for p.match(token.AND) {
 		operator := p.previous()
-		right := p.equality()
+		right := p.bitwiseOr()
 		expr = &ast.LogicalExpr{
 			Left:     expr,
 			Operator: operator,
@@ -290,6 +508,55 @@ func (p *Parser) and() ast.Expr {
 	return expr
 }
 
+// The bitwise operators bind more loosely than equality, as they do in C.
+func (p *Parser) bitwiseOr() ast.Expr {
+	expr := p.bitwiseXor()
+
+	for p.match(token.PIPE) {
+		operator := p.previous()
+		right := p.bitwiseXor()
+		expr = &ast.BinaryExpr{
+			Left:     expr,
+			Operator: operator,
+			Right:    right,
+		}
+	}
+
+	return expr
+}
+
+func (p *Parser) bitwiseXor() ast.Expr {
+	expr := p.bitwiseAnd()
+
+	for p.match(token.CARET) {
+		operator := p.previous()
+		right := p.bitwiseAnd()
+		expr = &ast.BinaryExpr{
+			Left:     expr,
+			Operator: operator,
+			Right:    right,
+		}
+	}
+
+	return expr
+}
+
+func (p *Parser) bitwiseAnd() ast.Expr {
+	expr := p.equality()
+
+	for p.match(token.AMPERSAND) {
+		operator := p.previous()
+		right := p.equality()
+		expr = &ast.BinaryExpr{
+			Left:     expr,
+			Operator: operator,
+			Right:    right,
+		}
+	}
+
+	return expr
+}
+
 func (p *Parser) equality() ast.Expr {
 	expr := p.comparison()
 
@@ -307,9 +574,25 @@ func (p *Parser) equality() ast.Expr {
 }
 
 func (p *Parser) comparison() ast.Expr {
-	expr := p.term()
+	expr := p.shift()
 
 	for p.match(token.GREATER, token.GREATER_EQUAL, token.LESS, token.LESS_EQUAL) {
+		operator := p.previous()
+		right := p.shift()
+		expr = &ast.BinaryExpr{
+			Left:     expr,
+			Operator: operator,
+			Right:    right,
+		}
+	}
+
+	return expr
+}
+
+func (p *Parser) shift() ast.Expr {
+	expr := p.term()
+
+	for p.match(token.LESS_LESS, token.GREATER_GREATER) {
 		operator := p.previous()
 		right := p.term()
 		expr = &ast.BinaryExpr{
@@ -341,7 +624,7 @@ func (p *Parser) term() ast.Expr {
 func (p *Parser) factor() ast.Expr {
 	expr := p.unary()
 
-	for p.match(token.SLASH, token.STAR) {
+	for p.match(token.SLASH, token.STAR, token.PERCENT, token.TILDE_SLASH) {
 		operator := p.previous()
 		right := p.unary()
 		expr = &ast.BinaryExpr{
@@ -355,7 +638,7 @@ func (p *Parser) factor() ast.Expr {
 }
 
 func (p *Parser) unary() ast.Expr {
-	if p.match(token.BANG, token.MINUS) {
+	if p.match(token.BANG, token.MINUS, token.TILDE) {
 		operator := p.previous()
 		right := p.unary()
 		return &ast.UnaryExpr{
@@ -364,11 +647,65 @@ func (p *Parser) unary() ast.Expr {
 		}
 	}
 
-	return p.call()
+	return p.exponent()
+}
+
+// exponent parses the right-associative "**" operator, which binds more
+// tightly than a unary operator on its left, so -2 ** 2 is -4.
+func (p *Parser) exponent() ast.Expr {
+	expr := p.prefix()
+
+	if p.match(token.STAR_STAR) {
+		operator := p.previous()
+		right := p.unary()
+		expr = &ast.BinaryExpr{
+			Left:     expr,
+			Operator: operator,
+			Right:    right,
+		}
+	}
+
+	return expr
+}
+
+// prefix parses a prefix "++" or "--". It binds more tightly than "**", so
+// ++a ** 2 squares the incremented value of a.
+func (p *Parser) prefix() ast.Expr {
+	if p.match(token.PLUS_PLUS, token.MINUS_MINUS) {
+		operator := p.previous()
+		target := p.postfix()
+		p.checkUpdateTarget(operator, target)
+		return &ast.UpdateExpr{Prefix: operator, Target: target}
+	}
+
+	return p.postfix()
+}
+
+func (p *Parser) postfix() ast.Expr {
+	expr := p.call()
+
+	if p.match(token.PLUS_PLUS, token.MINUS_MINUS) {
+		operator := p.previous()
+		p.checkUpdateTarget(operator, expr)
+		return &ast.UpdateExpr{Target: expr, Postfix: operator}
+	}
+
+	return expr
+}
+
+// checkUpdateTarget reports an error if the target of "++" or "--" is not a
+// variable or property.
+func (p *Parser) checkUpdateTarget(operator *token.Token, target ast.Expr) {
+	switch target.(type) {
+	case *ast.VariableExpr, *ast.GetExpr, *ast.IndexExpr:
+	default:
+		_ = p.error(operator, errs.InvalidAssignmentTarget, "Invalid increment target.")
+	}
 }
 
 func (p *Parser) call() ast.Expr {
 	expr := p.primary()
+	optional := false
 
 paramsLoop:
 	for {
@@ -378,21 +715,58 @@ paramsLoop:
 		case p.match(token.DOT):
 			name := p.consume(token.IDENTIFIER, "Expect property name after '.'.")
 			expr = &ast.GetExpr{Object: expr, Name: name}
+		case p.match(token.LEFT_BRACKET):
+			expr = p.finishIndex(expr)
+		case p.match(token.QUESTION_DOT):
+			name := p.consume(token.IDENTIFIER, "Expect property name after '?.'.")
+			expr = &ast.GetExpr{Object: expr, Name: name, Optional: true}
+			optional = true
 		default:
 			break paramsLoop
 		}
 	}
 
+	// A nil object before "?." skips the rest of the chain of calls and
+	// property accesses, so the chain as a whole needs to be marked.
+	if optional {
+		expr = &ast.OptionalChainExpr{Expression: expr}
+	}
+
 	return expr
 }
 
+// finishIndex parses an index such as "[i]", or a slice such as "[low:high]"
+// where either bound is optional, after its opening bracket.
+func (p *Parser) finishIndex(object ast.Expr) ast.Expr {
+	leftBracket := p.previous()
+
+	var index ast.Expr
+	if !p.check(token.COLON) {
+		index = p.expression()
+	}
+
+	if p.match(token.COLON) {
+		colon := p.previous()
+		var high ast.Expr
+		if !p.check(token.RIGHT_BRACKET) {
+			high = p.expression()
+		}
+
+		rightBracket := p.consume(token.RIGHT_BRACKET, "Expect ']' after slice.")
+		return &ast.SliceExpr{Object: object, LeftBracket: leftBracket, Low: index, Colon: colon, High: high, RightBracket: rightBracket}
+	}
+
+	rightBracket := p.consume(token.RIGHT_BRACKET, "Expect ']' after index.")
+	return &ast.IndexExpr{Object: object, LeftBracket: leftBracket, Index: index, RightBracket: rightBracket}
+}
+
 func (p *Parser) finishCall(callee ast.Expr) ast.Expr {
 	var arguments []ast.Expr
 
 	if !p.check(token.RIGHT_PAREN) {
 		for {
 			if len(arguments) >= 255 {
-				_ = p.error(p.peek(), "Can't have more than 255 arguments.")
+				_ = p.error(p.peek(), errs.TooManyArguments, "Can't have more than 255 arguments.")
 			}
 
 			arguments = append(arguments, p.expression())
@@ -414,17 +788,29 @@ func (p *Parser) finishCall(callee ast.Expr) ast.Expr {
 func (p *Parser) primary() ast.Expr {
 	switch {
 	case p.match(token.FALSE):
-		return &ast.LiteralExpr{Value: false}
+		return &ast.LiteralExpr{Token: p.previous(), Value: false}
 	case p.match(token.TRUE):
-		return &ast.LiteralExpr{Value: true}
+		return &ast.LiteralExpr{Token: p.previous(), Value: true}
 	case p.match(token.NIL):
-		return &ast.LiteralExpr{Value: nil}
+		return &ast.LiteralExpr{Token: p.previous(), Value: nil}
 	case p.match(token.NUMBER, token.STRING):
-		return &ast.LiteralExpr{Value: p.previous().Literal}
+		return &ast.LiteralExpr{Token: p.previous(), Value: p.previous().Literal}
+	case p.match(token.INTERPOLATION):
+		return p.interpolation()
+	case p.match(token.FUN):
+		return p.lambda()
+	case p.match(token.LEFT_BRACKET):
+		return p.list()
+	case p.match(token.LEFT_BRACE):
+		return p.mapLiteral()
+	case p.check(token.LEFT_PAREN) && p.isArrowFunction():
+		p.advance()
+		return p.arrowFunction()
 	case p.match(token.LEFT_PAREN):
+		leftParen := p.previous()
 		expr := p.expression()
-		p.consume(token.RIGHT_PAREN, "Expect ')' after expression.")
-		return &ast.GroupingExpr{Expression: expr}
+		rightParen := p.consume(token.RIGHT_PAREN, "Expect ')' after expression.")
+		return &ast.GroupingExpr{LeftParen: leftParen, Expression: expr, RightParen: rightParen}
 	case p.match(token.SUPER):
 		keyword := p.previous()
 		p.consume(token.DOT, "Expect '.' after 'super'.")
@@ -435,7 +821,82 @@ func (p *Parser) primary() ast.Expr {
 	case p.match(token.IDENTIFIER):
 		return &ast.VariableExpr{Name: p.previous()}
 	default:
-		panic(p.error(p.peek(), "Expect expression."))
+		panic(p.error(p.peek(), errs.ExpectedExpression, "Expect expression."))
+	}
+}
+
+// list parses a list literal after its opening bracket. A trailing comma is
+// allowed after the last element.
+func (p *Parser) list() ast.Expr {
+	leftBracket := p.previous()
+
+	var elements []ast.Expr
+	for !p.check(token.RIGHT_BRACKET) {
+		elements = append(elements, p.expression())
+		if !p.match(token.COMMA) {
+			break
+		}
+	}
+
+	rightBracket := p.consume(token.RIGHT_BRACKET, "Expect ']' after list elements.")
+	return &ast.ListExpr{LeftBracket: leftBracket, Elements: elements, RightBracket: rightBracket}
+}
+
+// isMapLiteral reports whether the '{' at the start of a statement or arrow
+// function body begins a map literal rather than a block. It does if the
+// first key is a literal or identifier followed by a colon, unless that is
+// the label of a loop. Other maps must be wrapped in parentheses there.
+func (p *Parser) isMapLiteral() bool {
+	switch p.lookahead(1).Type {
+	case token.STRING, token.NUMBER, token.TRUE, token.FALSE, token.NIL:
+		return p.lookahead(2).Type == token.COLON
+	case token.IDENTIFIER:
+		if p.lookahead(2).Type != token.COLON {
+			return false
+		}
+
+		next := p.lookahead(3).Type
+		return next != token.WHILE && next != token.FOR
+	default:
+		return false
+	}
+}
+
+// mapLiteral parses a map literal after its opening brace. A trailing comma
+// is allowed after the last entry.
+func (p *Parser) mapLiteral() ast.Expr {
+	leftBrace := p.previous()
+
+	var keys, values []ast.Expr
+	for !p.check(token.RIGHT_BRACE) {
+		keys = append(keys, p.expression())
+		p.consume(token.COLON, "Expect ':' after map key.")
+		values = append(values, p.expression())
+		if !p.match(token.COMMA) {
+			break
+		}
+	}
+
+	rightBrace := p.consume(token.RIGHT_BRACE, "Expect '}' after map entries.")
+	return &ast.MapExpr{LeftBrace: leftBrace, Keys: keys, Values: values, RightBrace: rightBrace}
+}
+
+// interpolation parses the rest of a string with embedded expressions after its
+// first INTERPOLATION token. The string parts become literals between the
+// embedded expressions.
+func (p *Parser) interpolation() ast.Expr {
+	parts := []ast.Expr{&ast.LiteralExpr{Token: p.previous(), Value: p.previous().Literal}}
+	for {
+		parts = append(parts, p.expression())
+
+		if p.match(token.INTERPOLATION) {
+			parts = append(parts, &ast.LiteralExpr{Token: p.previous(), Value: p.previous().Literal})
+			continue
+		}
+
+		end := p.consume(token.STRING, "Expect '}' after interpolated expression.")
+		parts = append(parts, &ast.LiteralExpr{Token: end, Value: end.Literal})
+		return &ast.InterpolationExpr{Parts: parts}
 	}
 }
 
@@ -471,7 +932,7 @@ func (p *Parser) consume(t token.Type, message string) *token.Token {
 		return p.advance()
 	}
 
-	panic(p.error(p.peek(), message))
+	panic(p.error(p.peek(), errs.ExpectedToken, message))
 }
 
 func (p *Parser) isAtEnd() bool {
@@ -479,38 +940,82 @@ func (p *Parser) isAtEnd() bool {
 }
 
 func (p *Parser) peek() *token.Token {
-	return p.tokens[p.current]
+	return p.lookahead(0)
+}
+
+// lookahead returns the token n tokens after the current one.
+func (p *Parser) lookahead(n int) *token.Token {
+	for p.current+n >= len(p.tokens) {
+		if len(p.tokens) == cap(p.tokens) {
+			// Tokens are stored in blocks rather than allocated individually.
+			// The AST points into the blocks, so a full block can't be reused.
+			tokens := make([]token.Token, len(p.tokens), 2*len(p.tokens)+64)
+			copy(tokens, p.tokens)
+			p.tokens = tokens
+		}
+
+		p.tokens = append(p.tokens, p.source.Next())
+	}
+
+	return &p.tokens[p.current+n]
 }
 
 func (p *Parser) previous() *token.Token {
-	return p.tokens[p.current-1]
+	return &p.tokens[p.current-1]
 }
 
-func (p *Parser) error(t *token.Token, message string) error {
-	errs.ErrorAtToken(t, message)
+func (p *Parser) error(t *token.Token, code errs.Code, message string) error {
+	if !p.panicMode {
+		p.errors.ErrorAtToken(t, code, message)
+		p.panicMode = true
+	}
+
 	return ErrParseError
 }
 
-func (p *Parser) synchronize() {
-	p.advance()
+// synchronize discards tokens until it reaches what looks like the start of
+// the next statement, or the end of the enclosing block, so that parsing can
+// continue after a syntax error in the statement which began at start.
+func (p *Parser) synchronize(start int) {
+	p.panicMode = false
 
-	for !p.isAtEnd() {
-		if p.previous().Type == token.SEMICOLON {
-			return
+	// Always make progress, so the same token can't cause an error twice.
+	if p.current == start {
+		p.advance()
+	}
+
+	// Braces opened by the broken statement are skipped up to the one which
+	// closes them, or it would be left over to be parsed as a statement.
+	depth := 0
+	for _, t := range p.tokens[start:p.current] {
+		switch t.Type {
+		case token.LEFT_BRACE:
+			depth++
+		case token.RIGHT_BRACE:
+			depth--
 		}
+	}
 
-		switch p.peek().Type {
-		case token.CLASS:
-		case token.FUN:
-		case token.VAR:
-		case token.FOR:
-		case token.IF:
-		case token.WHILE:
-		case token.PRINT:
-		case token.RETURN:
-			return
+	for !p.isAtEnd() {
+		if depth <= 0 {
+			if p.previous().Type == token.SEMICOLON {
+				return
+			}
+
+			switch p.peek().Type {
+			case token.CLASS, token.FUN, token.VAR, token.FOR, token.IF, token.WHILE, token.PRINT, token.RETURN, token.BREAK, token.CONTINUE, token.RIGHT_BRACE:
+				return
+			}
 		}
 
-		p.advance()
+		switch p.advance().Type {
+		case token.LEFT_BRACE:
+			depth++
+		case token.RIGHT_BRACE:
+			depth--
+			if depth == 0 {
+				return
+			}
+		}
 	}
 }
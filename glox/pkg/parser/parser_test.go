@@ -0,0 +1,164 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DomBlack/lox/glox/pkg/ast"
+	"github.com/DomBlack/lox/glox/pkg/scanner"
+	"github.com/DomBlack/lox/glox/pkg/token"
+)
+
+func parse(t *testing.T, source string) []ast.Stmt {
+	t.Helper()
+
+	tokens, diagnostics := scanner.New(source).ScanTokens()
+	stmts, parseDiagnostics := New(tokens).Parse()
+	diagnostics = append(diagnostics, parseDiagnostics...)
+	if len(diagnostics) > 0 {
+		t.Fatalf("Parse() diagnostics = %v", diagnostics)
+	}
+
+	return stmts
+}
+
+func TestNodePositions(t *testing.T) {
+	source := `class A < B { m(x) { return (x + 1); } }
+while (i < 3) i = i + 1;
+{ a.b = "c"; }`
+	stmts := parse(t, source)
+
+	class := stmts[0].(*ast.ClassStmt)
+	method := class.Methods[0]
+	ret := method.Body[0].(*ast.ReturnStmt)
+	loop := stmts[1].(*ast.WhileStmt)
+	set := stmts[2].(*ast.BlockStmt).Statements[0].(*ast.ExpressionStmt).Expression
+
+	tests := []struct {
+		name string
+		node interface {
+			Pos() token.Pos
+			End() token.Pos
+		}
+		want string
+	}{
+		{"class", class, "class A < B { m(x) { return (x + 1); } }"},
+		{"method", method, "m(x) { return (x + 1); }"},
+		{"return", ret, "return (x + 1);"},
+		{"grouping", ret.Value, "(x + 1)"},
+		{"binary", ret.Value.(*ast.GroupingExpr).Expression, "x + 1"},
+		{"while", loop, "while (i < 3) i = i + 1;"},
+		{"assign", loop.Body.(*ast.ExpressionStmt).Expression, "i = i + 1"},
+		{"block", stmts[2], `{ a.b = "c"; }`},
+		{"set", set, `a.b = "c"`},
+	}
+
+	for _, tt := range tests {
+		if got := source[tt.node.Pos():tt.node.End()]; got != tt.want {
+			t.Errorf("%s spans %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestErrorRecovery(t *testing.T) {
+	source := `var a = ;
+print a
+class A { m() { return 1 } }
+fun f() {
+  print 1 + ;
+  var = 2;
+  print "ok";
+}
+print (1;
+print "end";`
+
+	tokens, _ := scanner.New(source).ScanTokens()
+	stmts, diagnostics := New(tokens).Parse()
+
+	wantErrors := []string{
+		"[line 1] Error  at ';': Expect expression.",
+		"[line 3] Error  at 'class': Expect ';' after value.",
+		"[line 3] Error  at '}': Expect ';' after return value.",
+		"[line 5] Error  at ';': Expect expression.",
+		"[line 6] Error  at '=': Expect variable name.",
+		"[line 9] Error  at ';': Expect ')' after expression.",
+	}
+	if len(diagnostics) != len(wantErrors) {
+		t.Fatalf("Parse() diagnostics = %v, want %d errors", diagnostics, len(wantErrors))
+	}
+	for i, want := range wantErrors {
+		if got := diagnostics[i].Error(); got != want {
+			t.Errorf("diagnostic %d = %q, want %q", i, got, want)
+		}
+	}
+
+	var kinds []string
+	for _, stmt := range stmts {
+		kinds = append(kinds, fmt.Sprintf("%T", stmt))
+	}
+	want := "[*ast.VarStmt *ast.BadStmt *ast.ClassStmt *ast.FunctionStmt *ast.PrintStmt *ast.PrintStmt]"
+	if got := fmt.Sprint(kinds); got != want {
+		t.Errorf("Parse() statements = %s, want %s", got, want)
+	}
+
+	if _, ok := stmts[0].(*ast.VarStmt).Initializer.(*ast.BadExpr); !ok {
+		t.Errorf("var initializer = %T, want *ast.BadExpr", stmts[0].(*ast.VarStmt).Initializer)
+	}
+
+	body := stmts[3].(*ast.FunctionStmt).Body
+	if len(body) != 3 {
+		t.Fatalf("function body has %d statements, want 3", len(body))
+	}
+	if _, ok := body[1].(*ast.BadStmt); !ok {
+		t.Errorf("function body[1] = %T, want *ast.BadStmt", body[1])
+	}
+	if _, ok := body[2].(*ast.PrintStmt); !ok {
+		t.Errorf("function body[2] = %T, want *ast.PrintStmt", body[2])
+	}
+
+	// Recovering from an error must not leave a '}' behind to be reported again.
+	for source, want := range map[string]string{
+		"fun f( { print 1; }\nprint 2;":   "[line 1] Error  at '{': Expect parameter name.",
+		"if (x { print 1; }\nprint 2;":    "[line 1] Error  at '{': Expect ')' after if condition.",
+		"class A { m( { } }\nprint 2;":    "[line 1] Error  at '{': Expect parameter name.",
+		"{ print (1 }\nprint 2;":          "[line 1] Error  at '}': Expect ')' after expression.",
+		"fun f() { if (x { } }\nprint 2;": "[line 1] Error  at '{': Expect ')' after if condition.",
+	} {
+		tokens, _ := scanner.New(source).ScanTokens()
+		stmts, diagnostics := New(tokens).Parse()
+		if diagnostics.Error() != want {
+			t.Errorf("Parse(%q) diagnostics = %q, want %q", source, diagnostics.Error(), want)
+		}
+		if _, ok := stmts[len(stmts)-1].(*ast.PrintStmt); !ok {
+			t.Errorf("Parse(%q) last statement = %T, want *ast.PrintStmt", source, stmts[len(stmts)-1])
+		}
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"a ? b : c ? d : e;", "(?: a b (?: c d e))"},
+		{"a or b ? c = 1 : d;", "(?: (or a b) c = 1 d)"},
+		{"a ?? b or c ?? d;", "(?? (?? a (or b c)) d)"},
+		{"x = a ?? b ? c : d;", "x = (?: (?? a b) c d)"},
+		{"a?.b.c(1)?.d;", "a?.b.c(1)?.d"},
+		{"-a[1] ** 2;", "(- (** a[1] 2))"},
+		{"++a ** 2;", "(** (++ a) 2)"},
+		{"-++a ** --b;", "(- (** (++ a) (-- b)))"},
+		{"x = {a: b ? c : d, 1: e}[a];", "x = (map a (?: b c d) 1 e)[a]"},
+		{"{a: b, c: d}[a];", "(map a b c d)[a]"},
+		{"a[b][1:-1][c] = [c, d];", "a[b][1:(- 1)][c] = (list c d)"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			stmts := parse(t, test.source)
+			if got := ast.Print(stmts[0].(*ast.ExpressionStmt).Expression); got != test.want {
+				t.Errorf("Print() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
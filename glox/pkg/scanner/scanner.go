@@ -1,61 +1,196 @@
 package scanner
 
 import (
+	"fmt"
+	"io"
 	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/DomBlack/lox/glox/pkg/errs"
 	"github.com/DomBlack/lox/glox/pkg/token"
 )
 
 var keywords = map[string]token.Type{
-	"and":    token.AND,
-	"class":  token.CLASS,
-	"else":   token.ELSE,
-	"false":  token.FALSE,
-	"for":    token.FOR,
-	"fun":    token.FUN,
-	"if":     token.IF,
-	"nil":    token.NIL,
-	"or":     token.OR,
-	"print":  token.PRINT,
-	"return": token.RETURN,
-	"super":  token.SUPER,
-	"this":   token.THIS,
-	"true":   token.TRUE,
-	"var":    token.VAR,
-	"while":  token.WHILE,
+	"and":      token.AND,
+	"break":    token.BREAK,
+	"class":    token.CLASS,
+	"continue": token.CONTINUE,
+	"else":     token.ELSE,
+	"false":    token.FALSE,
+	"for":      token.FOR,
+	"fun":      token.FUN,
+	"if":       token.IF,
+	"nil":      token.NIL,
+	"or":       token.OR,
+	"print":    token.PRINT,
+	"return":   token.RETURN,
+	"super":    token.SUPER,
+	"this":     token.THIS,
+	"true":     token.TRUE,
+	"var":      token.VAR,
+	"while":    token.WHILE,
 }
 
+// Mode is a set of flags which control optional scanner behaviour.
+type Mode uint
+
+const (
+	// ScanTrivia records whitespace and comments as trivia on the tokens,
+	// rather than discarding them.
+	ScanTrivia Mode = 1 << iota
+)
+
+// readSize is the minimum number of bytes requested from the reader at a time.
+const readSize = 4096
+
+// Scanner produces tokens lazily from source code, which is read as needed
+// from an io.Reader.
 type Scanner struct {
-	source  string
-	tokens  []*token.Token
+	file    *token.File
+	reader  io.Reader // nil once the source has been read
+	buf     []byte    // the source which has been read, from the start of the current token
+	base    int       // offset of buf[0] in the source
+	queue   []token.Token
+	done    bool // whether the EOF token has been scanned
 	start   int
 	current int
 	line    int
+	errors  errs.Diagnostics
+	mode    Mode
+	trivia  []token.Trivia // trivia not yet attached to a token
+
+	// interpolations holds the depth of unclosed braces within each
+	// expression embedded in a string that we are currently scanning.
+	interpolations []int
 }
 
 func New(source string) *Scanner {
+	return NewFile("", source)
+}
+
+// NewFile creates a scanner for source read from the named file, which is
+// recorded in the positions of the tokens and diagnostics.
+func NewFile(filename string, source string) *Scanner {
+	return &Scanner{
+		file: token.NewFile(filename),
+		buf:  []byte(source),
+		line: 1,
+	}
+}
+
+// NewReader creates a scanner which reads the named file from r as tokens are
+// requested, so scanning can begin before the whole source is available.
+func NewReader(filename string, r io.Reader) *Scanner {
 	return &Scanner{
-		source:  source,
-		tokens:  make([]*token.Token, 0),
-		start:   0,
-		current: 0,
-		line:    1,
+		file:   token.NewFile(filename),
+		reader: r,
+		line:   1,
+	}
+}
+
+// SetMode sets the flags which control optional scanner behaviour.
+func (s *Scanner) SetMode(mode Mode) {
+	s.mode = mode
+}
+
+// ScanTokens scans the whole of the remaining source.
+func (s *Scanner) ScanTokens() ([]*token.Token, errs.Diagnostics) {
+	var tokens []token.Token
+	for {
+		tokens = append(tokens, s.Next())
+		if tokens[len(tokens)-1].Type == token.EOF {
+			break
+		}
+	}
+
+	pointers := make([]*token.Token, len(tokens))
+	for i := range tokens {
+		pointers[i] = &tokens[i]
+	}
+	return pointers, s.errors
+}
+
+// Next returns the next token. Once the end of the source has been reached it
+// returns an EOF token, and keeps doing so on every later call.
+func (s *Scanner) Next() token.Token {
+	// A token is only complete once the token after it has been scanned, as
+	// until then more trailing trivia could be attached to it.
+	for len(s.queue) < 2 && !s.done {
+		s.scan()
 	}
+
+	t := s.queue[0]
+	if len(s.queue) > 1 {
+		s.queue = s.queue[1:]
+	}
+	return t
+}
+
+// Errors returns the diagnostics reported for the tokens scanned so far.
+func (s *Scanner) Errors() errs.Diagnostics {
+	return s.errors
 }
 
-func (s *Scanner) ScanTokens() []*token.Token {
-	for !s.isAtEnd() {
-		s.start = s.current
-		s.scanToken()
+// scan scans the next token, which may be skipped trivia rather than a token.
+func (s *Scanner) scan() {
+	// The source before the current position is no longer needed.
+	s.buf = s.buf[s.current-s.base:]
+	s.base = s.current
+	s.start = s.current
+
+	if s.isAtEnd() {
+		s.addToken(token.EOF)
+		s.done = true
+		return
 	}
 
-	s.tokens = append(s.tokens, &token.Token{Type: token.EOF, Lexeme: "", Literal: nil, Line: s.line})
-	return s.tokens
+	s.scanToken()
 }
 
 func (s *Scanner) isAtEnd() bool {
-	return s.current >= len(s.source)
+	return s.current-s.base >= len(s.buf) && !s.fill()
+}
+
+// fill reads more of the source into the buffer, returning false if the reader
+// has been exhausted.
+func (s *Scanner) fill() bool {
+	for s.reader != nil {
+		if len(s.buf) == cap(s.buf) {
+			buf := make([]byte, len(s.buf), 2*len(s.buf)+readSize)
+			copy(buf, s.buf)
+			s.buf = buf
+		}
+
+		n, err := s.reader.Read(s.buf[len(s.buf):cap(s.buf)])
+		s.buf = s.buf[:len(s.buf)+n]
+		if err != nil {
+			if err != io.EOF {
+				s.errors.ErrorAtSpan(s.file, token.Pos(s.current), token.Pos(s.current), errs.ReadFailure, fmt.Sprintf("Could not read source: %v.", err))
+			}
+			s.reader = nil
+		}
+		if n > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ensure tries to read enough of the source for there to be n bytes after the
+// given offset, returning the bytes available after it.
+func (s *Scanner) ensure(offset, n int) []byte {
+	for len(s.buf)-(offset-s.base) < n && s.fill() {
+	}
+	return s.buf[offset-s.base:]
+}
+
+// text returns the source between two offsets at or after the start of the
+// current token.
+func (s *Scanner) text(from, to int) string {
+	return string(s.buf[from-s.base : to-s.base])
 }
 
 func (s *Scanner) scanToken() {
@@ -66,21 +201,76 @@ func (s *Scanner) scanToken() {
 	case ')':
 		s.addToken(token.RIGHT_PAREN)
 	case '{':
+		if n := len(s.interpolations); n > 0 {
+			s.interpolations[n-1]++
+		}
 		s.addToken(token.LEFT_BRACE)
 	case '}':
+		if n := len(s.interpolations); n > 0 {
+			if s.interpolations[n-1] == 0 {
+				// This closes an embedded expression, so the string continues.
+				s.interpolations = s.interpolations[:n-1]
+				s.string()
+				return
+			}
+			s.interpolations[n-1]--
+		}
 		s.addToken(token.RIGHT_BRACE)
+	case '[':
+		s.addToken(token.LEFT_BRACKET)
+	case ']':
+		s.addToken(token.RIGHT_BRACKET)
+	case ':':
+		s.addToken(token.COLON)
 	case ',':
 		s.addToken(token.COMMA)
 	case '.':
 		s.addToken(token.DOT)
 	case '-':
-		s.addToken(token.MINUS)
+		if s.match('-') {
+			s.addToken(token.MINUS_MINUS)
+		} else if s.match('=') {
+			s.addToken(token.MINUS_EQUAL)
+		} else {
+			s.addToken(token.MINUS)
+		}
 	case '+':
-		s.addToken(token.PLUS)
+		if s.match('+') {
+			s.addToken(token.PLUS_PLUS)
+		} else if s.match('=') {
+			s.addToken(token.PLUS_EQUAL)
+		} else {
+			s.addToken(token.PLUS)
+		}
 	case ';':
 		s.addToken(token.SEMICOLON)
+	case '&':
+		s.addToken(token.AMPERSAND)
+	case '|':
+		s.addToken(token.PIPE)
+	case '^':
+		s.addToken(token.CARET)
+	case '~':
+		// "//" starts a comment, so floor division is written "~/".
+		if s.match('/') {
+			s.addToken(token.TILDE_SLASH)
+		} else {
+			s.addToken(token.TILDE)
+		}
 	case '*':
-		s.addToken(token.STAR)
+		if s.match('*') {
+			s.addToken(token.STAR_STAR)
+		} else if s.match('=') {
+			s.addToken(token.STAR_EQUAL)
+		} else {
+			s.addToken(token.STAR)
+		}
+	case '%':
+		if s.match('=') {
+			s.addToken(token.PERCENT_EQUAL)
+		} else {
+			s.addToken(token.PERCENT)
+		}
 	case '!':
 		if s.match('=') {
 			s.addToken(token.BANG_EQUAL)
@@ -90,26 +280,45 @@ func (s *Scanner) scanToken() {
 	case '=':
 		if s.match('=') {
 			s.addToken(token.EQUAL_EQUAL)
+		} else if s.match('>') {
+			s.addToken(token.ARROW)
 		} else {
 			s.addToken(token.EQUAL)
 		}
 	case '<':
 		if s.match('=') {
 			s.addToken(token.LESS_EQUAL)
+		} else if s.match('<') {
+			s.addToken(token.LESS_LESS)
 		} else {
 			s.addToken(token.LESS)
 		}
 	case '>':
 		if s.match('=') {
 			s.addToken(token.GREATER_EQUAL)
+		} else if s.match('>') {
+			s.addToken(token.GREATER_GREATER)
 		} else {
 			s.addToken(token.GREATER)
 		}
+	case '?':
+		if s.match('?') {
+			s.addToken(token.QUESTION_QUESTION)
+		} else if s.match('.') {
+			s.addToken(token.QUESTION_DOT)
+		} else {
+			s.addToken(token.QUESTION)
+		}
 	case '/':
 		if s.match('/') {
 			for s.peek() != '\n' && !s.isAtEnd() {
 				s.advance()
 			}
+			s.addTrivia(token.LineComment)
+		} else if s.match('*') {
+			s.blockComment()
+		} else if s.match('=') {
+			s.addToken(token.SLASH_EQUAL)
 		} else {
 			s.addToken(token.SLASH)
 		}
@@ -118,94 +327,297 @@ func (s *Scanner) scanToken() {
 		s.string()
 
 	case ' ', '\r', '\t':
-		// Ignore whitespace.
+		for s.peek() == ' ' || s.peek() == '\r' || s.peek() == '\t' {
+			s.advance()
+		}
+		s.addTrivia(token.Whitespace)
 
 	case '\n':
-		s.line++
+		s.newline()
+		s.addTrivia(token.Newline)
 
 	default:
 		if isDigit(c) {
 			s.number()
 		} else if isAlpha(c) {
 			s.identifier()
+		} else if c == utf8.RuneError && s.current-s.start == 1 {
+			// Already reported as invalid UTF-8 by advance.
 		} else {
-			errs.ErrorOnLine(s.line, "Unexpected character.")
+			s.errors.ErrorAtSpan(s.file, token.Pos(s.start), token.Pos(s.current), errs.UnexpectedCharacter, "Unexpected character.")
 		}
 	}
 }
 
-func (s *Scanner) advance() byte {
-	s.current++
-	return s.source[s.current-1]
+func (s *Scanner) advance() rune {
+	r, size := utf8.DecodeRune(s.ensure(s.current, utf8.UTFMax))
+	if r == utf8.RuneError && size == 1 {
+		s.errors.ErrorAtSpan(s.file, token.Pos(s.current), token.Pos(s.current+1), errs.InvalidUTF8, "Invalid UTF-8 encoding.")
+	}
+	s.current += size
+	return r
 }
 
-func (s *Scanner) match(expected byte) bool {
+func (s *Scanner) match(expected rune) bool {
 	if s.isAtEnd() {
 		return false
 	}
-	if s.source[s.current] != expected {
+	if s.peek() != expected {
 		return false
 	}
-	s.current++
+	s.advance()
 	return true
 }
 
-func (s *Scanner) peek() byte {
+// newline records that a line has ended just before the current character.
+func (s *Scanner) newline() {
+	s.line++
+	s.file.AddLine(s.current)
+}
+
+func (s *Scanner) peek() rune {
 	if s.isAtEnd() {
 		return 0
 	}
-	return s.source[s.current]
+	r, _ := utf8.DecodeRune(s.ensure(s.current, utf8.UTFMax))
+	return r
 }
 
-func (s *Scanner) peekNext() byte {
-	if s.current+1 >= len(s.source) {
+func (s *Scanner) peekNext() rune {
+	if s.isAtEnd() {
 		return 0
 	}
-	return s.source[s.current+1]
+	next := s.ensure(s.current, 2*utf8.UTFMax)
+	_, size := utf8.DecodeRune(next)
+	if size >= len(next) {
+		return 0
+	}
+	r, _ := utf8.DecodeRune(next[size:])
+	return r
 }
 
+// string scans the rest of a string after its opening quote, or after the
+// closing brace of an embedded expression. If the string contains another
+// embedded expression, it emits an INTERPOLATION token up to the "${" and
+// leaves the expression to be scanned as normal tokens.
 func (s *Scanner) string() {
+	var value strings.Builder
 	for s.peek() != '"' && !s.isAtEnd() {
-		if s.peek() == '\n' {
-			s.line++
+		c := s.advance()
+		switch c {
+		case '\n':
+			s.newline()
+		case '$':
+			if s.match('{') {
+				s.interpolations = append(s.interpolations, 0)
+				s.addTokenLiteral(token.INTERPOLATION, value.String())
+				return
+			}
+		case '\\':
+			if r, ok := s.escape(); ok {
+				value.WriteRune(r)
+			}
+			continue
 		}
-		s.advance()
+		value.WriteRune(c)
 	}
 
 	if s.isAtEnd() {
-		errs.ErrorOnLine(s.line, "Unterminated string.")
+		s.errors.ErrorAtSpan(s.file, token.Pos(s.start), token.Pos(s.current), errs.UnterminatedString, "Unterminated string.")
 		return
 	}
 
 	s.advance()
 
-	value := s.source[s.start+1 : s.current-1]
-	s.addTokenLiteral(token.STRING, value)
+	s.addTokenLiteral(token.STRING, value.String())
 }
 
+// escapes maps the single character escape sequences to the rune they represent.
+var escapes = map[rune]rune{
+	'n':  '\n',
+	't':  '\t',
+	'r':  '\r',
+	'0':  0,
+	'"':  '"',
+	'$':  '$',
+	'\\': '\\',
+}
+
+// escape scans an escape sequence after its backslash within a string,
+// returning false if it is invalid, in which case an error has been reported.
+func (s *Scanner) escape() (rune, bool) {
+	start := s.current - 1
+	if s.isAtEnd() {
+		return 0, false
+	}
+
+	c := s.advance()
+	if c == '\n' {
+		s.newline()
+	}
+	if r, ok := escapes[c]; ok {
+		return r, true
+	}
+
+	if c != 'u' {
+		s.errors.ErrorAtSpan(s.file, token.Pos(start), token.Pos(s.current), errs.InvalidEscape, "Invalid escape sequence.")
+		return 0, false
+	}
+
+	// \u{X} to \u{XXXXXX}
+	if !s.match('{') {
+		s.errors.ErrorAtSpan(s.file, token.Pos(start), token.Pos(s.current), errs.InvalidEscape, "Expect '{' after '\\u'.")
+		return 0, false
+	}
+	digits := s.current
+	for isHexDigit(s.peek()) {
+		s.advance()
+	}
+	hex := s.text(digits, s.current)
+	if !s.match('}') {
+		s.errors.ErrorAtSpan(s.file, token.Pos(start), token.Pos(s.current), errs.InvalidEscape, "Expect '}' after unicode escape.")
+		return 0, false
+	}
+
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) > 6 || !utf8.ValidRune(rune(value)) {
+		s.errors.ErrorAtSpan(s.file, token.Pos(start), token.Pos(s.current), errs.InvalidEscape, "Invalid unicode code point.")
+		return 0, false
+	}
+	return rune(value), true
+}
+
+// blockComment scans a comment after its opening "/*", which ends at the
+// matching "*/" so that comments can be nested.
+func (s *Scanner) blockComment() {
+	depth := 1
+	for depth > 0 {
+		switch {
+		case s.isAtEnd():
+			s.errors.ErrorAtSpan(s.file, token.Pos(s.start), token.Pos(s.current), errs.UnterminatedComment, "Unterminated block comment.")
+			return
+		case s.peek() == '/' && s.peekNext() == '*':
+			s.advance()
+			s.advance()
+			depth++
+		case s.peek() == '*' && s.peekNext() == '/':
+			s.advance()
+			s.advance()
+			depth--
+		default:
+			if s.advance() == '\n' {
+				s.newline()
+			}
+		}
+	}
+
+	s.addTrivia(token.BlockComment)
+}
+
+// bases maps the prefix letter of a non-decimal number literal to its base.
+var bases = map[rune]int{
+	'x': 16, 'X': 16,
+	'o': 8, 'O': 8,
+	'b': 2, 'B': 2,
+}
+
+// number scans a number literal after its first digit. All numbers are
+// float64, so integer literals which are too large lose precision rather than
+// being rejected.
 func (s *Scanner) number() {
-	for isDigit(s.peek()) {
+	if base, ok := bases[s.peek()]; ok && s.buf[s.start-s.base] == '0' {
 		s.advance()
+		s.integer(base)
+		return
 	}
 
+	if !s.digits(10) {
+		return
+	}
 	if s.peek() == '.' && isDigit(s.peekNext()) {
 		s.advance()
+		if !s.digits(10) {
+			return
+		}
+	}
 
-		for isDigit(s.peek()) {
+	if s.peek() == 'e' || s.peek() == 'E' {
+		s.advance()
+		if s.peek() == '+' || s.peek() == '-' {
 			s.advance()
 		}
+		if !isDigit(s.peek()) {
+			s.invalidNumber("Expect digits in exponent.")
+			return
+		}
+		if !s.digits(10) {
+			return
+		}
+	}
+
+	text := strings.ReplaceAll(s.text(s.start, s.current), "_", "")
+	value, _ := strconv.ParseFloat(text, 64)
+	s.addTokenLiteral(token.NUMBER, value)
+}
+
+// integer scans the digits of a hex, octal or binary literal after its prefix.
+func (s *Scanner) integer(base int) {
+	if !isDigitIn(s.peek(), base) {
+		s.invalidNumber(fmt.Sprintf("Expect digits after '%s'.", s.text(s.start, s.current)))
+		return
+	}
+	if !s.digits(base) {
+		return
 	}
 
-	value, _ := strconv.ParseFloat(s.source[s.start:s.current], 64)
+	value := 0.0
+	for _, c := range s.text(s.start+2, s.current) {
+		if c != '_' {
+			value = value*float64(base) + float64(digitValue(c))
+		}
+	}
 	s.addTokenLiteral(token.NUMBER, value)
 }
 
+// digits scans a run of digits in the given base, which may be separated by
+// single underscores. If a separator is misplaced, or the run is followed by
+// a letter or digit which cannot be part of it, it reports the literal as
+// invalid and returns false.
+func (s *Scanner) digits(base int) bool {
+	separated := true
+	for isDigitIn(s.peek(), base) || s.peek() == '_' {
+		if s.advance() == '_' && !isDigitIn(s.peek(), base) {
+			separated = false
+		}
+	}
+
+	if isAlphaNumeric(s.peek()) && !(base == 10 && (s.peek() == 'e' || s.peek() == 'E')) {
+		for isAlphaNumeric(s.peek()) {
+			s.advance()
+		}
+		s.invalidNumber(fmt.Sprintf("Invalid digit in base %d literal.", base))
+		return false
+	}
+	if !separated {
+		s.invalidNumber("Digit separators must be between two digits.")
+		return false
+	}
+	return true
+}
+
+// invalidNumber reports an error for the number literal scanned so far. It
+// still emits a NUMBER token so the parser does not report errors of its own.
+func (s *Scanner) invalidNumber(message string) {
+	s.errors.ErrorAtSpan(s.file, token.Pos(s.start), token.Pos(s.current), errs.InvalidNumber, message)
+	s.addTokenLiteral(token.NUMBER, 0.0)
+}
+
 func (s *Scanner) identifier() {
 	for isAlphaNumeric(s.peek()) {
 		s.advance()
 	}
 
-	text := s.source[s.start:s.current]
+	text := s.text(s.start, s.current)
 	tokenType, ok := keywords[text]
 	if !ok {
 		tokenType = token.IDENTIFIER
@@ -218,18 +630,88 @@ func (s *Scanner) addToken(tokenType token.Type) {
 }
 
 func (s *Scanner) addTokenLiteral(tokenType token.Type, literal any) {
-	text := s.source[s.start:s.current]
-	s.tokens = append(s.tokens, &token.Token{Type: tokenType, Lexeme: text, Literal: literal, Line: s.line})
+	text := s.text(s.start, s.current)
+	position := s.file.Position(token.Pos(s.start))
+	t := token.Token{
+		Type:    tokenType,
+		Lexeme:  text,
+		Literal: literal,
+		Line:    position.Line,
+		File:    s.file,
+		Column:  position.Column,
+		Start:   token.Pos(s.start),
+		End:     token.Pos(s.current),
+	}
+
+	if len(s.trivia) > 0 {
+		s.attachTrivia(&t)
+	}
+	s.queue = append(s.queue, t)
 }
 
-func isDigit(c byte) bool {
+// addTrivia records the text just scanned as trivia, if in ScanTrivia mode.
+func (s *Scanner) addTrivia(kind token.TriviaKind) {
+	if s.mode&ScanTrivia == 0 {
+		return
+	}
+
+	s.trivia = append(s.trivia, token.Trivia{
+		Kind:  kind,
+		Text:  s.text(s.start, s.current),
+		Start: token.Pos(s.start),
+		End:   token.Pos(s.current),
+	})
+}
+
+// attachTrivia attaches the trivia scanned since the previous token: that on
+// the same line as the previous token trails it, and the rest leads t.
+func (s *Scanner) attachTrivia(t *token.Token) {
+	trivia := s.trivia
+	if len(s.queue) > 0 {
+		previous := &s.queue[len(s.queue)-1]
+		for len(trivia) > 0 && !strings.Contains(trivia[0].Text, "\n") {
+			previous.TrailingTrivia = append(previous.TrailingTrivia, trivia[0])
+			trivia = trivia[1:]
+		}
+	}
+
+	if len(trivia) > 0 {
+		t.LeadingTrivia = trivia
+	}
+	s.trivia = nil
+}
+
+func isDigit(c rune) bool {
 	return c >= '0' && c <= '9'
 }
 
-func isAlpha(c byte) bool {
-	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+func isHexDigit(c rune) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func isDigitIn(c rune, base int) bool {
+	return isHexDigit(c) && digitValue(c) < base
+}
+
+// digitValue returns the value of a hex digit.
+func digitValue(c rune) int {
+	switch {
+	case c >= 'a':
+		return int(c-'a') + 10
+	case c >= 'A':
+		return int(c-'A') + 10
+	default:
+		return int(c - '0')
+	}
+}
+
+// isAlpha reports whether c can start an identifier, which is any Unicode
+// letter or an underscore.
+func isAlpha(c rune) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' ||
+		(c >= utf8.RuneSelf && unicode.IsLetter(c))
 }
 
-func isAlphaNumeric(c byte) bool {
-	return isAlpha(c) || isDigit(c)
+func isAlphaNumeric(c rune) bool {
+	return isAlpha(c) || isDigit(c) || (c >= utf8.RuneSelf && unicode.IsDigit(c))
 }
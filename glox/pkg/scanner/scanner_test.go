@@ -0,0 +1,343 @@
+package scanner
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/DomBlack/lox/glox/pkg/token"
+)
+
+func TestTokenPositions(t *testing.T) {
+	tokens, diagnostics := NewFile("test.lox", "var a = \"x\ny\";\n  print a;").ScanTokens()
+	if len(diagnostics) != 0 {
+		t.Fatalf("ScanTokens() diagnostics = %v", diagnostics)
+	}
+
+	want := []struct {
+		typ          token.Type
+		line, column int
+		start, end   token.Pos
+	}{
+		{token.VAR, 1, 1, 0, 3},
+		{token.IDENTIFIER, 1, 5, 4, 5},
+		{token.EQUAL, 1, 7, 6, 7},
+		{token.STRING, 1, 9, 8, 13},
+		{token.SEMICOLON, 2, 3, 13, 14},
+		{token.PRINT, 3, 3, 17, 22},
+		{token.IDENTIFIER, 3, 9, 23, 24},
+		{token.SEMICOLON, 3, 10, 24, 25},
+		{token.EOF, 3, 11, 25, 25},
+	}
+	if len(tokens) != len(want) {
+		t.Fatalf("ScanTokens() = %d tokens, want %d", len(tokens), len(want))
+	}
+
+	for i, tok := range tokens {
+		w := want[i]
+		if tok.Type != w.typ || tok.Line != w.line || tok.Column != w.column || tok.Start != w.start || tok.End != w.end {
+			t.Errorf("token %d = %s at %d:%d [%d, %d), want %s at %d:%d [%d, %d)",
+				i, tok.Type, tok.Line, tok.Column, tok.Start, tok.End, w.typ, w.line, w.column, w.start, w.end)
+		}
+	}
+
+	if got := tokens[5].Position().String(); got != "test.lox:3:3" {
+		t.Errorf("Position() = %q, want %q", got, "test.lox:3:3")
+	}
+}
+
+func TestBlockComments(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		types []token.Type
+		line  int // line of the EOF token
+		err   string
+	}{
+		{"simple", "1 /* two */ 3", []token.Type{token.NUMBER, token.NUMBER, token.EOF}, 1, ""},
+		{"nested", "1 /* a /* b */ c */ 3", []token.Type{token.NUMBER, token.NUMBER, token.EOF}, 1, ""},
+		{"multiline", "/*\n\n*/ 1", []token.Type{token.NUMBER, token.EOF}, 3, ""},
+		{"slash star", "1 / *", []token.Type{token.NUMBER, token.SLASH, token.STAR, token.EOF}, 1, ""},
+		{"unterminated", "1 /* a /* b */", []token.Type{token.NUMBER, token.EOF}, 1, "Unterminated block comment."},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tokens, diagnostics := New(test.input).ScanTokens()
+
+			var types []token.Type
+			for _, tok := range tokens {
+				types = append(types, tok.Type)
+			}
+			if !reflect.DeepEqual(types, test.types) {
+				t.Errorf("ScanTokens() types = %v, want %v", types, test.types)
+			}
+			if eof := tokens[len(tokens)-1]; eof.Line != test.line {
+				t.Errorf("EOF line = %d, want %d", eof.Line, test.line)
+			}
+
+			switch {
+			case test.err == "" && len(diagnostics) != 0:
+				t.Errorf("ScanTokens() diagnostics = %v", diagnostics)
+			case test.err != "" && (len(diagnostics) != 1 || diagnostics[0].Message != test.err):
+				t.Errorf("ScanTokens() diagnostics = %v, want %q", diagnostics, test.err)
+			}
+		})
+	}
+}
+
+func TestTrivia(t *testing.T) {
+	source := "// header\nvar a = 1; // trailing\n/* doc */ print a;"
+	scanner := New(source)
+	scanner.SetMode(ScanTrivia)
+	tokens, diagnostics := scanner.ScanTokens()
+	if len(diagnostics) != 0 {
+		t.Fatalf("ScanTokens() diagnostics = %v", diagnostics)
+	}
+
+	texts := func(trivia []token.Trivia) []string {
+		var texts []string
+		for _, t := range trivia {
+			texts = append(texts, t.Text)
+		}
+		return texts
+	}
+
+	tests := []struct {
+		index    int
+		leading  []string
+		trailing []string
+	}{
+		{0, []string{"// header", "\n"}, []string{" "}}, // var
+		{4, nil, []string{" ", "// trailing"}},          // ;
+		{5, []string{"\n", "/* doc */", " "}, []string{" "}},
+		{8, nil, nil}, // EOF
+	}
+	for _, test := range tests {
+		tok := tokens[test.index]
+		if got := texts(tok.LeadingTrivia); !reflect.DeepEqual(got, test.leading) {
+			t.Errorf("%s leading trivia = %q, want %q", tok.Type, got, test.leading)
+		}
+		if got := texts(tok.TrailingTrivia); !reflect.DeepEqual(got, test.trailing) {
+			t.Errorf("%s trailing trivia = %q, want %q", tok.Type, got, test.trailing)
+		}
+	}
+
+	// Every byte of the source is covered by either a token or trivia.
+	var rebuilt strings.Builder
+	for _, tok := range tokens {
+		for _, t := range tok.LeadingTrivia {
+			rebuilt.WriteString(t.Text)
+		}
+		rebuilt.WriteString(tok.Lexeme)
+		for _, t := range tok.TrailingTrivia {
+			rebuilt.WriteString(t.Text)
+		}
+	}
+	if rebuilt.String() != source {
+		t.Errorf("rebuilt source = %q, want %q", rebuilt.String(), source)
+	}
+}
+
+func TestStrings(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		value string
+		err   string
+		start token.Pos // start of the error span
+		end   token.Pos
+	}{
+		{"plain", `"hello"`, "hello", "", 0, 0},
+		{"escapes", `"a\tb\nc\r\"d\"\\\0"`, "a\tb\nc\r\"d\"\\\x00", "", 0, 0},
+		{"unicode escape", `"\u{48}\u{e9}\u{1F600}"`, "Hé😀", "", 0, 0},
+		{"utf-8", `"héllo, 世界"`, "héllo, 世界", "", 0, 0},
+		{"unknown escape", `"ab\qc"`, "abc", "Invalid escape sequence.", 3, 5},
+		{"missing brace", `"\u48"`, "48", "Expect '{' after '\\u'.", 1, 3},
+		{"unclosed brace", `"\u{48"`, "", "Expect '}' after unicode escape.", 1, 6},
+		{"empty code point", `"\u{}"`, "", "Invalid unicode code point.", 1, 5},
+		{"surrogate", `"x\u{D800}"`, "x", "Invalid unicode code point.", 2, 10},
+		{"too large", `"\u{110000}"`, "", "Invalid unicode code point.", 1, 11},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tokens, diagnostics := New(test.input).ScanTokens()
+			if tokens[0].Type != token.STRING || tokens[0].Literal != test.value {
+				t.Errorf("ScanTokens() = %s %q, want STRING %q", tokens[0].Type, tokens[0].Literal, test.value)
+			}
+
+			if test.err == "" {
+				if len(diagnostics) != 0 {
+					t.Errorf("ScanTokens() diagnostics = %v", diagnostics)
+				}
+				return
+			}
+			if len(diagnostics) != 1 {
+				t.Fatalf("ScanTokens() diagnostics = %v, want %q", diagnostics, test.err)
+			}
+			d := diagnostics[0]
+			if d.Message != test.err || d.Start != int(test.start) || d.End != int(test.end) {
+				t.Errorf("diagnostic = %q at [%d, %d), want %q at [%d, %d)", d.Message, d.Start, d.End, test.err, test.start, test.end)
+			}
+		})
+	}
+}
+
+func TestUnicode(t *testing.T) {
+	tokens, diagnostics := New("var π = 3; print café_2 ± 1;").ScanTokens()
+
+	var got []string
+	for _, tok := range tokens {
+		got = append(got, tok.Lexeme)
+	}
+	want := []string{"var", "π", "=", "3", ";", "print", "café_2", "1", ";", ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ScanTokens() lexemes = %q, want %q", got, want)
+	}
+
+	if len(diagnostics) != 1 || diagnostics[0].Message != "Unexpected character." || diagnostics[0].End-diagnostics[0].Start != len("±") {
+		t.Errorf("ScanTokens() diagnostics = %v, want one unexpected character", diagnostics)
+	}
+
+	_, diagnostics = New("print \xff;").ScanTokens()
+	if len(diagnostics) != 1 || diagnostics[0].Message != "Invalid UTF-8 encoding." {
+		t.Errorf("ScanTokens() diagnostics = %v, want invalid UTF-8", diagnostics)
+	}
+}
+
+func TestFloorDivision(t *testing.T) {
+	tokens, diagnostics := New("a ~/ b ~c // a comment, not division\n").ScanTokens()
+	if len(diagnostics) != 0 {
+		t.Fatalf("ScanTokens() diagnostics = %v", diagnostics)
+	}
+
+	var got []token.Type
+	for _, tok := range tokens {
+		got = append(got, tok.Type)
+	}
+	want := []token.Type{token.IDENTIFIER, token.TILDE_SLASH, token.IDENTIFIER, token.TILDE, token.IDENTIFIER, token.EOF}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ScanTokens() types = %v, want %v", got, want)
+	}
+}
+
+func TestInterpolation(t *testing.T) {
+	tokens, diagnostics := New(`"a ${b + "${c}"} d ${ {} }"`).ScanTokens()
+	if len(diagnostics) != 0 {
+		t.Fatalf("ScanTokens() diagnostics = %v", diagnostics)
+	}
+
+	type tok struct {
+		Type    token.Type
+		Lexeme  string
+		Literal any
+	}
+	want := []tok{
+		{token.INTERPOLATION, `"a ${`, "a "},
+		{token.IDENTIFIER, "b", nil},
+		{token.PLUS, "+", nil},
+		{token.INTERPOLATION, `"${`, ""},
+		{token.IDENTIFIER, "c", nil},
+		{token.STRING, `}"`, ""},
+		{token.INTERPOLATION, `} d ${`, " d "},
+		{token.LEFT_BRACE, "{", nil},
+		{token.RIGHT_BRACE, "}", nil},
+		{token.STRING, `}"`, ""},
+		{token.EOF, "", nil},
+	}
+
+	var got []tok
+	for _, t := range tokens {
+		got = append(got, tok{t.Type, t.Lexeme, t.Literal})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ScanTokens() = %v, want %v", got, want)
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	tests := []struct {
+		input string
+		value float64
+		err   string
+	}{
+		{"123", 123, ""},
+		{"123.45", 123.45, ""},
+		{"1_000_000", 1000000, ""},
+		{"1e9", 1e9, ""},
+		{"6.02e-23", 6.02e-23, ""},
+		{"1_0.2_5E+1_0", 10.25e10, ""},
+		{"0xFF", 255, ""},
+		{"0Xdead_beef", 0xdeadbeef, ""},
+		{"0b1010", 10, ""},
+		{"0o755", 0o755, ""},
+		{"0x10000000000000000", 1 << 64, ""},
+		{"0x", 0, "Expect digits after '0x'."},
+		{"0b_1", 0, "Expect digits after '0b'."},
+		{"0b102", 0, "Invalid digit in base 2 literal."},
+		{"0o8", 0, "Expect digits after '0o'."},
+		{"12abc", 0, "Invalid digit in base 10 literal."},
+		{"1__0", 0, "Digit separators must be between two digits."},
+		{"1_", 0, "Digit separators must be between two digits."},
+		{"1_.5", 0, "Digit separators must be between two digits."},
+		{"1e", 0, "Expect digits in exponent."},
+		{"1e+", 0, "Expect digits in exponent."},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			tokens, diagnostics := New(test.input).ScanTokens()
+			if tokens[0].Type != token.NUMBER || tokens[0].Literal != test.value {
+				t.Errorf("ScanTokens() = %s %v, want NUMBER %v", tokens[0].Type, tokens[0].Literal, test.value)
+			}
+
+			switch {
+			case test.err == "" && len(diagnostics) != 0:
+				t.Errorf("ScanTokens() diagnostics = %v", diagnostics)
+			case test.err != "" && (len(diagnostics) != 1 || diagnostics[0].Message != test.err):
+				t.Errorf("ScanTokens() diagnostics = %v, want %q", diagnostics, test.err)
+			}
+		})
+	}
+}
+
+func TestReader(t *testing.T) {
+	source := "// π\nvar s = \"héllo ${1 + 2}\"; /* a\n/* b */ */\nprint s; 0x1F"
+	want, _ := New(source).ScanTokens()
+
+	// Read a byte at a time, so runes and tokens are split across reads.
+	scanner := NewReader("", iotest.OneByteReader(strings.NewReader(source)))
+	for i, w := range want {
+		got := scanner.Next()
+		if got.Position() != w.Position() {
+			t.Errorf("Next() token %d position = %v, want %v", i, got.Position(), w.Position())
+		}
+
+		got.File, w.File = nil, nil
+		if !reflect.DeepEqual(&got, w) {
+			t.Fatalf("Next() token %d = %+v, want %+v", i, got, *w)
+		}
+	}
+	if got := scanner.Next(); got.Type != token.EOF {
+		t.Errorf("Next() after EOF = %s, want EOF", got.Type)
+	}
+	if len(scanner.Errors()) != 0 {
+		t.Errorf("Errors() = %v", scanner.Errors())
+	}
+}
+
+func TestReaderError(t *testing.T) {
+	scanner := NewReader("", io.MultiReader(strings.NewReader("print 1"), iotest.ErrReader(errors.New("broken pipe"))))
+	tokens, diagnostics := scanner.ScanTokens()
+
+	if len(tokens) != 3 || tokens[1].Literal != 1.0 || tokens[2].Type != token.EOF {
+		t.Errorf("ScanTokens() = %v, want tokens before the error", tokens)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Message != "Could not read source: broken pipe." {
+		t.Errorf("ScanTokens() diagnostics = %v, want read error", diagnostics)
+	}
+}
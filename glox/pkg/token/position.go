@@ -0,0 +1,73 @@
+package token
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Pos is a byte offset into a source file.
+type Pos int
+
+// NoPos is the position of a token which does not appear in the source, such
+// as one synthesized by the parser.
+const NoPos Pos = -1
+
+// Position describes a location in a source file in terms of lines and columns.
+type Position struct {
+	Filename string
+	Offset   int // byte offset, starting at 0
+	Line     int // starting at 1
+	Column   int // byte offset within the line, starting at 1
+}
+
+func (p Position) String() string {
+	filename := p.Filename
+	if filename == "" {
+		filename = "<input>"
+	}
+
+	return fmt.Sprintf("%s:%d:%d", filename, p.Line, p.Column)
+}
+
+// File records the name of a source file and the offsets at which its lines
+// start, so that a Pos can be converted into a Position.
+type File struct {
+	Name  string
+	lines []int
+}
+
+func NewFile(name string) *File {
+	return &File{Name: name, lines: []int{0}}
+}
+
+// AddLine records that a new line starts at the given offset, which must be
+// greater than the offset of the previously added line.
+func (f *File) AddLine(offset int) {
+	if offset > f.lines[len(f.lines)-1] {
+		f.lines = append(f.lines, offset)
+	}
+}
+
+// LineStart returns the offset of the start of the given 1-based line.
+func (f *File) LineStart(line int) Pos {
+	if line < 1 || line > len(f.lines) {
+		return NoPos
+	}
+
+	return Pos(f.lines[line-1])
+}
+
+// Position converts a Pos into a line and column.
+func (f *File) Position(p Pos) Position {
+	if p < 0 {
+		return Position{Filename: f.Name}
+	}
+
+	line := sort.Search(len(f.lines), func(i int) bool { return f.lines[i] > int(p) }) - 1
+	return Position{
+		Filename: f.Name,
+		Offset:   int(p),
+		Line:     line + 1,
+		Column:   int(p) - f.lines[line] + 1,
+	}
+}
@@ -9,6 +9,26 @@ type Token struct {
 	Lexeme  string
 	Literal any
 	Line    int
+
+	File   *File // the file the token was scanned from, or nil if synthesized
+	Column int   // 1-based byte column of the start of the token
+	Start  Pos   // offset of the first byte of the token
+	End    Pos   // offset just past the last byte of the token
+
+	// Trivia is only recorded when scanning with scanner.ScanTrivia. Trailing
+	// trivia is that which follows the token on the same line; all other
+	// trivia leads the next token.
+	LeadingTrivia  []Trivia
+	TrailingTrivia []Trivia
+}
+
+// Position returns the position of the start of the token.
+func (t *Token) Position() Position {
+	if t.File == nil {
+		return Position{Line: t.Line, Column: t.Column, Offset: int(t.Start)}
+	}
+
+	return t.File.Position(t.Start)
 }
 
 func (t *Token) String() string {
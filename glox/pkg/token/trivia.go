@@ -0,0 +1,36 @@
+package token
+
+// TriviaKind is the kind of a piece of trivia.
+type TriviaKind uint8
+
+const (
+	Whitespace   TriviaKind = iota // spaces, tabs and carriage returns
+	Newline                        // a single '\n'
+	LineComment                    // a "//" comment, not including the newline which ends it
+	BlockComment                   // a "/* */" comment, which may be nested and span lines
+)
+
+func (k TriviaKind) String() string {
+	switch k {
+	case Whitespace:
+		return "Whitespace"
+	case Newline:
+		return "Newline"
+	case LineComment:
+		return "LineComment"
+	case BlockComment:
+		return "BlockComment"
+	default:
+		return "TriviaKind(?)"
+	}
+}
+
+// Trivia is source text which does not affect the meaning of a program, such as
+// whitespace and comments. It is only recorded when the scanner is asked to
+// preserve it, and is attached to the tokens either side of it.
+type Trivia struct {
+	Kind  TriviaKind
+	Text  string
+	Start Pos
+	End   Pos
+}
@@ -9,32 +9,58 @@ const (
 	RIGHT_PAREN
 	LEFT_BRACE
 	RIGHT_BRACE
+	LEFT_BRACKET
+	RIGHT_BRACKET
+	AMPERSAND
+	CARET
+	COLON
 	COMMA
 	DOT
 	MINUS
+	PERCENT
+	PIPE
 	PLUS
 	SEMICOLON
 	SLASH
 	STAR
+	TILDE
 
 	// One or two character tokens.
+	ARROW
 	BANG
 	BANG_EQUAL
 	EQUAL
 	EQUAL_EQUAL
 	GREATER
 	GREATER_EQUAL
+	GREATER_GREATER
 	LESS
 	LESS_EQUAL
+	LESS_LESS
+	MINUS_EQUAL
+	MINUS_MINUS
+	PERCENT_EQUAL
+	PLUS_EQUAL
+	PLUS_PLUS
+	SLASH_EQUAL
+	STAR_EQUAL
+	STAR_STAR
+	TILDE_SLASH
+	QUESTION
+	QUESTION_DOT
+	QUESTION_QUESTION
 
 	// Literals.
 	IDENTIFIER
 	STRING
 	NUMBER
+	INTERPOLATION // a string up to an embedded "${", or between two embedded expressions
 
 	// Keywords.
 	AND
+	BREAK
 	CLASS
+	CONTINUE
 	ELSE
 	FALSE
 	FUN
@@ -12,46 +12,72 @@ func _() {
 	_ = x[RIGHT_PAREN-1]
 	_ = x[LEFT_BRACE-2]
 	_ = x[RIGHT_BRACE-3]
-	_ = x[COMMA-4]
-	_ = x[DOT-5]
-	_ = x[MINUS-6]
-	_ = x[PLUS-7]
-	_ = x[SEMICOLON-8]
-	_ = x[SLASH-9]
-	_ = x[STAR-10]
-	_ = x[BANG-11]
-	_ = x[BANG_EQUAL-12]
-	_ = x[EQUAL-13]
-	_ = x[EQUAL_EQUAL-14]
-	_ = x[GREATER-15]
-	_ = x[GREATER_EQUAL-16]
-	_ = x[LESS-17]
-	_ = x[LESS_EQUAL-18]
-	_ = x[IDENTIFIER-19]
-	_ = x[STRING-20]
-	_ = x[NUMBER-21]
-	_ = x[AND-22]
-	_ = x[CLASS-23]
-	_ = x[ELSE-24]
-	_ = x[FALSE-25]
-	_ = x[FUN-26]
-	_ = x[FOR-27]
-	_ = x[IF-28]
-	_ = x[NIL-29]
-	_ = x[OR-30]
-	_ = x[PRINT-31]
-	_ = x[RETURN-32]
-	_ = x[SUPER-33]
-	_ = x[THIS-34]
-	_ = x[TRUE-35]
-	_ = x[VAR-36]
-	_ = x[WHILE-37]
-	_ = x[EOF-38]
+	_ = x[LEFT_BRACKET-4]
+	_ = x[RIGHT_BRACKET-5]
+	_ = x[AMPERSAND-6]
+	_ = x[CARET-7]
+	_ = x[COLON-8]
+	_ = x[COMMA-9]
+	_ = x[DOT-10]
+	_ = x[MINUS-11]
+	_ = x[PERCENT-12]
+	_ = x[PIPE-13]
+	_ = x[PLUS-14]
+	_ = x[SEMICOLON-15]
+	_ = x[SLASH-16]
+	_ = x[STAR-17]
+	_ = x[TILDE-18]
+	_ = x[ARROW-19]
+	_ = x[BANG-20]
+	_ = x[BANG_EQUAL-21]
+	_ = x[EQUAL-22]
+	_ = x[EQUAL_EQUAL-23]
+	_ = x[GREATER-24]
+	_ = x[GREATER_EQUAL-25]
+	_ = x[GREATER_GREATER-26]
+	_ = x[LESS-27]
+	_ = x[LESS_EQUAL-28]
+	_ = x[LESS_LESS-29]
+	_ = x[MINUS_EQUAL-30]
+	_ = x[MINUS_MINUS-31]
+	_ = x[PERCENT_EQUAL-32]
+	_ = x[PLUS_EQUAL-33]
+	_ = x[PLUS_PLUS-34]
+	_ = x[SLASH_EQUAL-35]
+	_ = x[STAR_EQUAL-36]
+	_ = x[STAR_STAR-37]
+	_ = x[TILDE_SLASH-38]
+	_ = x[QUESTION-39]
+	_ = x[QUESTION_DOT-40]
+	_ = x[QUESTION_QUESTION-41]
+	_ = x[IDENTIFIER-42]
+	_ = x[STRING-43]
+	_ = x[NUMBER-44]
+	_ = x[INTERPOLATION-45]
+	_ = x[AND-46]
+	_ = x[BREAK-47]
+	_ = x[CLASS-48]
+	_ = x[CONTINUE-49]
+	_ = x[ELSE-50]
+	_ = x[FALSE-51]
+	_ = x[FUN-52]
+	_ = x[FOR-53]
+	_ = x[IF-54]
+	_ = x[NIL-55]
+	_ = x[OR-56]
+	_ = x[PRINT-57]
+	_ = x[RETURN-58]
+	_ = x[SUPER-59]
+	_ = x[THIS-60]
+	_ = x[TRUE-61]
+	_ = x[VAR-62]
+	_ = x[WHILE-63]
+	_ = x[EOF-64]
 }
 
-const _Type_name = "LEFT_PARENRIGHT_PARENLEFT_BRACERIGHT_BRACECOMMADOTMINUSPLUSSEMICOLONSLASHSTARBANGBANG_EQUALEQUALEQUAL_EQUALGREATERGREATER_EQUALLESSLESS_EQUALIDENTIFIERSTRINGNUMBERANDCLASSELSEFALSEFUNFORIFNILORPRINTRETURNSUPERTHISTRUEVARWHILEEOF"
+const _Type_name = "LEFT_PARENRIGHT_PARENLEFT_BRACERIGHT_BRACELEFT_BRACKETRIGHT_BRACKETAMPERSANDCARETCOLONCOMMADOTMINUSPERCENTPIPEPLUSSEMICOLONSLASHSTARTILDEARROWBANGBANG_EQUALEQUALEQUAL_EQUALGREATERGREATER_EQUALGREATER_GREATERLESSLESS_EQUALLESS_LESSMINUS_EQUALMINUS_MINUSPERCENT_EQUALPLUS_EQUALPLUS_PLUSSLASH_EQUALSTAR_EQUALSTAR_STARTILDE_SLASHQUESTIONQUESTION_DOTQUESTION_QUESTIONIDENTIFIERSTRINGNUMBERINTERPOLATIONANDBREAKCLASSCONTINUEELSEFALSEFUNFORIFNILORPRINTRETURNSUPERTHISTRUEVARWHILEEOF"
 
-var _Type_index = [...]uint8{0, 10, 21, 31, 42, 47, 50, 55, 59, 68, 73, 77, 81, 91, 96, 107, 114, 127, 131, 141, 151, 157, 163, 166, 171, 175, 180, 183, 186, 188, 191, 193, 198, 204, 209, 213, 217, 220, 225, 228}
+var _Type_index = [...]uint16{0, 10, 21, 31, 42, 54, 67, 76, 81, 86, 91, 94, 99, 106, 110, 114, 123, 128, 132, 137, 142, 146, 156, 161, 172, 179, 192, 207, 211, 221, 230, 241, 252, 265, 275, 284, 295, 305, 314, 325, 333, 345, 362, 372, 378, 384, 397, 400, 405, 410, 418, 422, 427, 430, 433, 435, 438, 440, 445, 451, 456, 460, 464, 467, 472, 475}
 
 func (i Type) String() string {
 	if i >= Type(len(_Type_index)-1) {
@@ -6,32 +6,49 @@ import (
 	"strings"
 )
 
+// Each type is described as "Name : Fields : Pos : End", where Pos and End
+// list the fields the node's start and end positions are taken from, in order
+// of preference, as fields may be nil in nodes synthesized by the parser.
 func main() {
 	defineAST("Expr", []string{
-		"Assign   : Name *token.Token,Value Expr",
-		"Binary   : Left Expr,Operator *token.Token,Right Expr",
-		"Call     : Callee Expr,Paren *token.Token,Arguments []Expr",
-		"Get      : Object Expr,Name *token.Token",
-		"Grouping : Expression Expr",
-		"Logical  : Left Expr,Operator *token.Token,Right Expr",
-		"Literal  : Value any",
-		"Set      : Object Expr,Name *token.Token,Value Expr",
-		"Super    : Keyword *token.Token,Method *token.Token",
-		"This     : Keyword *token.Token",
-		"Unary    : Operator *token.Token,Right Expr",
-		"Variable : Name *token.Token",
+		"Assign   : Name *token.Token,Operator *token.Token,Value Expr       : Name            : Value Operator Name",
+		"Bad      : From *token.Token,To *token.Token                        : From            : To",
+		"Binary   : Left Expr,Operator *token.Token,Right Expr               : Left Operator   : Right Operator",
+		"Call     : Callee Expr,Paren *token.Token,Arguments []Expr          : Callee Paren    : Paren",
+		"Conditional : Condition Expr,Question *token.Token,ThenBranch Expr,Colon *token.Token,ElseBranch Expr : Condition Question : ElseBranch Colon",
+		"Function : Keyword *token.Token,LeftParen *token.Token,Params []*token.Token,Body []Stmt,RightBrace *token.Token : Keyword LeftParen : RightBrace Body",
+		"Get      : Object Expr,Name *token.Token,Optional bool              : Object Name     : Name",
+		"OptionalChain : Expression Expr                                     : Expression      : Expression",
+		"Index    : Object Expr,LeftBracket *token.Token,Index Expr,RightBracket *token.Token : Object LeftBracket : RightBracket Index",
+		"IndexSet : Object Expr,LeftBracket *token.Token,Index Expr,RightBracket *token.Token,Operator *token.Token,Value Expr : Object LeftBracket : Value Operator RightBracket",
+		"Grouping : LeftParen *token.Token,Expression Expr,RightParen *token.Token : LeftParen Expression : RightParen Expression",
+		"Logical  : Left Expr,Operator *token.Token,Right Expr               : Left Operator   : Right Operator",
+		"Interpolation : Parts []Expr                                        : Parts           : Parts",
+		"List     : LeftBracket *token.Token,Elements []Expr,RightBracket *token.Token : LeftBracket Elements : RightBracket Elements",
+		"Map      : LeftBrace *token.Token,Keys []Expr,Values []Expr,RightBrace *token.Token : LeftBrace : RightBrace",
+		"Literal  : Token *token.Token,Value any                             : Token           : Token",
+		"Set      : Object Expr,Name *token.Token,Operator *token.Token,Value Expr : Object Name : Value Operator Name",
+		"Slice    : Object Expr,LeftBracket *token.Token,Low Expr,Colon *token.Token,High Expr,RightBracket *token.Token : Object LeftBracket : RightBracket High Colon",
+		"Super    : Keyword *token.Token,Method *token.Token                 : Keyword         : Method Keyword",
+		"This     : Keyword *token.Token                                     : Keyword         : Keyword",
+		"Update   : Prefix *token.Token,Target Expr,Postfix *token.Token     : Prefix Target   : Postfix Target",
+		"Unary    : Operator *token.Token,Right Expr                         : Operator        : Right Operator",
+		"Variable : Name *token.Token                                        : Name            : Name",
 	})
 
 	defineAST("Stmt", []string{
-		"Block      : Statements []Stmt",
-		"Class      : Name *token.Token,Superclass *VariableExpr,Methods []*FunctionStmt",
-		"Expression : Expression Expr",
-		"Function   : Name *token.Token,Params []*token.Token,Body []Stmt",
-		"If		    : Condition Expr,ThenBranch Stmt,ElseBranch Stmt",
-		"Print      : Expression Expr",
-		"Return     : Keyword *token.Token,Value Expr",
-		"Var        : Name *token.Token,Initializer Expr",
-		"While      : Condition Expr,Body Stmt",
+		"Bad        : From *token.Token,To *token.Token                      : From            : To",
+		"Break      : Keyword *token.Token,Label *token.Token,Semicolon *token.Token : Keyword : Semicolon Label Keyword",
+		"Block      : LeftBrace *token.Token,Statements []Stmt,RightBrace *token.Token : LeftBrace Statements : RightBrace Statements",
+		"Class      : Keyword *token.Token,Name *token.Token,Superclass *VariableExpr,Methods []*FunctionStmt,RightBrace *token.Token : Keyword Name : RightBrace Methods Superclass Name",
+		"Continue   : Keyword *token.Token,Label *token.Token,Semicolon *token.Token : Keyword : Semicolon Label Keyword",
+		"Expression : Expression Expr,Semicolon *token.Token                 : Expression Semicolon : Semicolon Expression",
+		"Function   : Keyword *token.Token,Name *token.Token,Params []*token.Token,Body []Stmt,RightBrace *token.Token : Keyword Name : RightBrace Body Name",
+		"If         : Keyword *token.Token,Condition Expr,ThenBranch Stmt,ElseBranch Stmt : Keyword Condition : ElseBranch ThenBranch",
+		"Print      : Keyword *token.Token,Expression Expr,Semicolon *token.Token : Keyword : Semicolon Expression Keyword",
+		"Return     : Keyword *token.Token,Value Expr,Semicolon *token.Token  : Keyword         : Semicolon Value Keyword",
+		"Var        : Keyword *token.Token,Name *token.Token,Initializer Expr,Semicolon *token.Token : Keyword Name : Semicolon Initializer Name",
+		"While      : Label *token.Token,Keyword *token.Token,Condition Expr,Body Stmt,Increment Expr : Label Keyword Condition : Body Increment Condition",
 	})
 }
 
@@ -59,28 +76,71 @@ func defineAST(baseName string, types []string) {
 		fmt.Fprintf(file, ")\n\n")
 	}
 	fmt.Fprintf(file, "type %s interface {\n", baseName)
+	fmt.Fprintf(file, "  Pos() token.Pos // position of the first character of the node\n")
+	fmt.Fprintf(file, "  End() token.Pos // position just past the last character of the node\n")
 	fmt.Fprintf(file, "  _%s() // unexported interface\n", strings.ToLower(baseName))
 	fmt.Fprintf(file, "}\n\n")
 
 	defineVisitor(file, baseName, types)
 
 	for _, t := range types {
-		className, fields, _ := strings.Cut(t, ":")
-		defineType(file, baseName, strings.TrimSpace(className), strings.TrimSpace(fields))
+		parts := strings.Split(t, ":")
+		defineType(file, baseName, strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), strings.Fields(parts[2]), strings.Fields(parts[3]))
 	}
 }
 
-func defineType(file *os.File, baseName, className string, fields string) {
+func defineType(file *os.File, baseName, className string, fields string, pos, end []string) {
 	fmt.Fprintf(file, "type %s%s struct {\n", className, baseName)
 
+	fieldTypes := make(map[string]string)
 	for _, field := range strings.Split(fields, ",") {
 		fieldName, fieldType, _ := strings.Cut(field, " ")
-		fmt.Fprintf(file, "  %s %s\n", strings.TrimSpace(fieldName), strings.TrimSpace(fieldType))
+		fieldName, fieldType = strings.TrimSpace(fieldName), strings.TrimSpace(fieldType)
+		fieldTypes[fieldName] = fieldType
+		fmt.Fprintf(file, "  %s %s\n", fieldName, fieldType)
 	}
 
 	fmt.Fprintf(file, "}\n")
 	fmt.Fprintf(file, "var _ %s = (*%s%s)(nil)\n\n", baseName, className, baseName)
 	fmt.Fprintf(file, "func (e *%s%s) _%s() {}\n\n", className, baseName, strings.ToLower(baseName))
+
+	definePosition(file, baseName, className, "Pos", pos, fieldTypes)
+	definePosition(file, baseName, className, "End", end, fieldTypes)
+}
+
+// definePosition defines the Pos or End method of a node, returning the
+// position of the first of the given fields which is present.
+func definePosition(file *os.File, baseName, className, method string, fields []string, fieldTypes map[string]string) {
+	tokenField := "Start"
+	if method == "End" {
+		tokenField = "End"
+	}
+
+	fmt.Fprintf(file, "func (e *%s%s) %s() token.Pos {\n", className, baseName, method)
+	for _, field := range fields {
+		fieldType, ok := fieldTypes[field]
+		if !ok {
+			panic(fmt.Sprintf("unknown field %s in %s%s", field, className, baseName))
+		}
+
+		index := "0"
+		if method == "End" {
+			index = fmt.Sprintf("len(e.%s)-1", field)
+		}
+
+		switch {
+		case fieldType == "*token.Token":
+			fmt.Fprintf(file, "  if e.%s != nil {\n    return e.%s.%s\n  }\n", field, field, tokenField)
+		case fieldType == "[]*token.Token":
+			fmt.Fprintf(file, "  if len(e.%s) > 0 {\n    return e.%s[%s].%s\n  }\n", field, field, index, tokenField)
+		case strings.HasPrefix(fieldType, "[]"):
+			fmt.Fprintf(file, "  if len(e.%s) > 0 {\n    return e.%s[%s].%s()\n  }\n", field, field, index, method)
+		default:
+			fmt.Fprintf(file, "  if e.%s != nil {\n    return e.%s.%s()\n  }\n", field, field, method)
+		}
+	}
+	fmt.Fprintf(file, "  return token.NoPos\n")
+	fmt.Fprintf(file, "}\n\n")
 }
 
 func defineVisitor(file *os.File, baseName string, types []string) {